@@ -1,8 +1,8 @@
 // Package backoff provides configurable exponential backoff functionality using Go iterators.
 //
 // This package offers a flexible and composable approach to implementing retry logic
-// with support for exponential and constant backoff strategies, jitter, context cancellation,
-// and early termination through cancel errors.
+// with support for exponential and constant backoff strategies, custom strategies through the
+// Strategy interface, jitter, context cancellation, and early termination through cancel errors.
 //
 // The package leverages Go 1.23+ range-over-func iterators for a clean and intuitive API.
 //
@@ -38,9 +38,13 @@ package backoff
 
 import (
 	"context"
+	"errors"
 	"iter"
 	"math"
 	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -68,6 +72,72 @@ func Cancel(err error) error {
 	return CancelError{Err: err}
 }
 
+// IsCancel reports whether any error in err's tree is a CancelError.
+// The tree is walked with errors.As, so cancel errors wrapped with fmt.Errorf("%w")
+// or combined with errors.Join are detected.
+//
+// Example:
+//
+//	err := fmt.Errorf("load config: %w", backoff.Cancel(errors.New("file not found")))
+//	backoff.IsCancel(err) // true
+func IsCancel(err error) bool {
+	var cancelErr CancelError
+	return errors.As(err, &cancelErr)
+}
+
+// RetryAfterError wraps an error with a hint of how long to wait before the next attempt,
+// such as the Retry-After header of an HTTP 429 or 503 response.
+// Use RetryAfter() to create one.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// Error returns the error message of the wrapped error.
+// This implements the error interface.
+func (e RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+// This allows RetryAfterError to work with Go's error unwrapping functions like errors.Is and errors.As.
+func (e RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the hinted delay before the next attempt.
+func (e RetryAfterError) RetryAfter() time.Duration {
+	return e.Delay
+}
+
+// RetryAfter wraps an error to ask for the next attempt to be made after d.
+// RetryFunc, RetryWithContext and Retry wait for d instead of the next delay of the
+// schedule, limited to MaxDelay and to the time left of MaxElapsedTime. If the wait would
+// not end before the context deadline, retries stop at once with a *RetryError whose
+// ContextErr is context.DeadlineExceeded.
+//
+// Any error in the tree that has a RetryAfter() time.Duration method is honored the
+// same way, so errors from other libraries can carry hints without being wrapped.
+//
+// Example:
+//
+//	if resp.StatusCode == http.StatusTooManyRequests {
+//	    return nil, backoff.RetryAfter(errors.New("rate limited"), 30*time.Second)
+//	}
+func RetryAfter(err error, d time.Duration) error {
+	return RetryAfterError{Err: err, Delay: d}
+}
+
+// retryAfter returns the delay hinted by the first error in err's tree that has a
+// RetryAfter method.
+func retryAfter(err error) (time.Duration, bool) {
+	var hint interface{ RetryAfter() time.Duration }
+	if !errors.As(err, &hint) {
+		return 0, false
+	}
+	return hint.RetryAfter(), true
+}
+
 // Option is a function that configures backoff behavior.
 // Options are applied to modify backoff parameters like delays, retry limits, and jitter.
 type Option func(*config)
@@ -76,8 +146,40 @@ type config struct {
 	initialDelay time.Duration
 	maxDelay     time.Duration
 	multiplier   float64
-	jitterFactor float64
+	jitter       JitterMode
 	maxRetries   int
+	maxElapsed   time.Duration
+	strategy     func(*config) Strategy
+	clock        Clock
+	rand         *rand.Rand
+
+	// retry loop settings, ignored by Iter
+	attemptTimeout time.Duration
+	retryIf        []func(error) bool
+	retryWhile     func(any, error) (bool, error)
+	breaker        *CircuitBreaker
+	budget         *RetryBudget
+	maxInFlight    int
+}
+
+// newConfig returns the default configuration with options applied in order.
+func newConfig(options ...Option) *config {
+	cfg := &config{
+		maxRetries:  math.MaxInt,
+		clock:       realClock{},
+		maxInFlight: 3,
+	}
+	Exponential()(cfg)
+
+	// Apply user options to override defaults
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	if cfg.maxDelay < cfg.initialDelay {
+		cfg.maxDelay = cfg.initialDelay
+	}
+	return cfg
 }
 
 // InitialDelay sets the initial delay duration for the first retry attempt.
@@ -134,6 +236,7 @@ func Multiplier(m float64) Option {
 // JitterFactor sets the amount of randomness to add to delays to avoid thundering herd problems.
 // The factor represents a percentage (0.1 = 10% jitter). If factor < 0, it defaults to 0.
 // Jitter is applied as a random value between -factor*delay and +factor*delay.
+// It is shorthand for Jitter(Symmetric(factor)); a factor of 0 disables jitter.
 //
 // Example:
 //
@@ -142,10 +245,11 @@ func Multiplier(m float64) Option {
 //	}
 func JitterFactor(factor float64) Option {
 	return func(c *config) {
-		if factor < 0 {
-			factor = 0
+		if factor <= 0 {
+			c.jitter = nil
+			return
 		}
-		c.jitterFactor = factor
+		c.jitter = Symmetric(factor)
 	}
 }
 
@@ -167,6 +271,43 @@ func MaxRetries(retries int) Option {
 	}
 }
 
+// MaxElapsedTime bounds the total time spent backing off, measured from the start of the
+// iteration (for RetryFunc, RetryWithContext and Retry: from the start of the first attempt).
+// Once the budget is spent no further delays are yielded, and the final delay is shortened
+// so that the loop ends near the budget instead of sleeping past it.
+// If d is <= 0, elapsed time is not bounded (the default).
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.MaxElapsedTime(2*time.Minute)) {
+//	    // Will stop retrying after roughly 2 minutes
+//	}
+func MaxElapsedTime(d time.Duration) Option {
+	return func(c *config) {
+		c.maxElapsed = max(0, d)
+	}
+}
+
+// WithRand sets the random number generator used for jitter and randomized strategies such
+// as Decorrelated. By default the global generator from math/rand/v2 is used. Passing a
+// generator created from a fixed seed makes jittered schedules reproducible, for example
+// in tests and simulations. If r is nil, the global generator is used.
+//
+// A *rand.Rand is not safe for concurrent use, so r must not be shared by iterators or
+// retry loops running in different goroutines. To replay a schedule, iterate with a new
+// generator created from the same seed.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.WithRand(rand.New(rand.NewPCG(1, 2)))) {
+//	    // The same seed always yields the same jittered delays
+//	}
+func WithRand(r *rand.Rand) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
 // Constant returns an Option that configures a constant backoff strategy.
 // All retry delays will be the same duration (default 1 second) with no jitter.
 // Use with other options to customize the constant delay duration.
@@ -185,7 +326,8 @@ func Constant() Option {
 		c.initialDelay = 1 * time.Second
 		c.maxDelay = 1 * time.Second
 		c.multiplier = 1.0
-		c.jitterFactor = 0.0
+		c.jitter = nil
+		c.strategy = newConstant
 	}
 }
 
@@ -207,13 +349,164 @@ func Exponential() Option {
 		c.initialDelay = 100 * time.Millisecond
 		c.maxDelay = 30 * time.Second
 		c.multiplier = 2.0
-		c.jitterFactor = 0.1
+		c.jitter = Symmetric(0.1)
+		c.strategy = newExponential
+	}
+}
+
+// Fibonacci returns an Option that configures a Fibonacci backoff strategy.
+// Delays grow as initialDelay × Fib(n): 1, 1, 2, 3, 5, 8, 13, ... times the initial delay,
+// capped at MaxDelay. This ramps up more gently than a 2.0 multiplier but faster than linear growth.
+// Uses the same defaults as Exponential: 100ms initial delay, 30s max delay, 10% jitter.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.Fibonacci(), backoff.MaxRetries(6)) {
+//	    // Will retry 6 times: ~100ms, ~100ms, ~200ms, ~300ms, ~500ms, ~800ms
+//	}
+func Fibonacci() Option {
+	return func(c *config) {
+		c.initialDelay = 100 * time.Millisecond
+		c.maxDelay = 30 * time.Second
+		c.jitter = Symmetric(0.1)
+		c.strategy = newFibonacci
+	}
+}
+
+// Linear returns an Option that configures a linear backoff strategy.
+// Each delay is step longer than the previous one, starting from the initial delay, which
+// defaults to step: Linear(100*time.Millisecond) yields 100ms, 200ms, 300ms, ... capped at MaxDelay.
+// If step is <= 0, it defaults to 100 milliseconds. Uses a 30s max delay and 10% jitter,
+// like Exponential.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.Linear(500*time.Millisecond), backoff.MaxRetries(4)) {
+//	    // Will retry 4 times: ~500ms, ~1s, ~1.5s, ~2s
+//	}
+//
+//	for delay := range backoff.Iter(backoff.Linear(time.Second), backoff.InitialDelay(5*time.Second)) {
+//	    // Delays will be ~5s, ~6s, ~7s, ...
+//	}
+func Linear(step time.Duration) Option {
+	return func(c *config) {
+		if step <= 0 {
+			step = 100 * time.Millisecond
+		}
+		c.initialDelay = step
+		c.maxDelay = 30 * time.Second
+		c.jitter = Symmetric(0.1)
+		c.strategy = func(c *config) Strategy {
+			return linear{initial: c.initialDelay, step: step}
+		}
+	}
+}
+
+// Polynomial returns an Option that configures a polynomial backoff strategy.
+// Delays grow as initialDelay × n^exponent for the n-th retry, so Polynomial(2) yields
+// 1, 4, 9, 16, ... times the initial delay, capped at MaxDelay.
+// If exponent is <= 0, it defaults to 2. Uses the same defaults as Exponential: 100ms
+// initial delay, 30s max delay, 10% jitter.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.Polynomial(2), backoff.MaxRetries(4)) {
+//	    // Will retry 4 times: ~100ms, ~400ms, ~900ms, ~1.6s
+//	}
+func Polynomial(exponent float64) Option {
+	return func(c *config) {
+		if exponent <= 0 {
+			exponent = 2
+		}
+		c.initialDelay = 100 * time.Millisecond
+		c.maxDelay = 30 * time.Second
+		c.jitter = Symmetric(0.1)
+		c.strategy = func(c *config) Strategy {
+			return polynomial{initial: c.initialDelay, exponent: exponent}
+		}
+	}
+}
+
+// Schedule returns an Option that yields exactly the given delays, in order, and then stops.
+// Jitter is disabled and MaxDelay is raised to the longest delay so the schedule is followed
+// as written; options applied afterwards, such as JitterFactor, MaxDelay and MaxRetries,
+// still take effect.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.Schedule(time.Second, 5*time.Second, 30*time.Second)) {
+//	    // Will retry 3 times: 1s, 5s, 30s
+//	}
+func Schedule(delays ...time.Duration) Option {
+	return newSchedule(delays, scheduleOnce)
+}
+
+// ScheduleRepeatLast is like Schedule, but keeps yielding the last delay once the list is
+// exhausted, until MaxRetries or MaxElapsedTime ends the iteration.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.ScheduleRepeatLast(
+//	    time.Second, 5*time.Second, 30*time.Second, 2*time.Minute, 5*time.Minute,
+//	)) {
+//	    // 1s, 5s, 30s, 2m, then every 5m
+//	}
+func ScheduleRepeatLast(delays ...time.Duration) Option {
+	return newSchedule(delays, scheduleRepeatLast)
+}
+
+// ScheduleCycle is like Schedule, but starts over from the first delay once the list is
+// exhausted, until MaxRetries or MaxElapsedTime ends the iteration.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.ScheduleCycle(time.Second, 10*time.Second), backoff.MaxRetries(4)) {
+//	    // 1s, 10s, 1s, 10s
+//	}
+func ScheduleCycle(delays ...time.Duration) Option {
+	return newSchedule(delays, scheduleCycle)
+}
+
+func newSchedule(delays []time.Duration, mode scheduleMode) Option {
+	delays = slices.Clone(delays)
+	return func(c *config) {
+		c.jitter = nil
+		if len(delays) > 0 {
+			c.initialDelay = max(0, delays[0])
+			c.maxDelay = max(0, slices.Max(delays))
+		}
+		c.strategy = func(*config) Strategy {
+			return schedule{delays: delays, mode: mode}
+		}
+	}
+}
+
+// Decorrelated returns an Option that configures a decorrelated jitter backoff strategy.
+// Each delay is drawn uniformly from [initialDelay, previousDelay*3] and capped at MaxDelay,
+// which spreads out clients that start retrying at the same time far better than symmetric
+// jitter around a shared exponential curve.
+// Uses the same defaults as Exponential: 100ms initial delay and 30s max delay. No additional
+// jitter is applied, since the strategy is already randomized.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.Decorrelated(), backoff.MaxRetries(5)) {
+//	    // First delay will be between 100ms and 300ms, each later delay
+//	    // between 100ms and three times the previous one
+//	}
+func Decorrelated() Option {
+	return func(c *config) {
+		c.initialDelay = 100 * time.Millisecond
+		c.maxDelay = 30 * time.Second
+		c.jitter = nil
+		c.strategy = newDecorrelated
 	}
 }
 
 // Iter returns an iterator that yields backoff delay durations.
 // If no options are provided, it defaults to exponential backoff with sensible defaults.
 // The iterator will yield delay durations that should be waited before each retry attempt.
+// Delays are computed by the configured Strategy, then capped at MaxDelay and jittered.
 //
 // The iterator supports Go's range-over-func feature (Go 1.23+):
 //
@@ -245,46 +538,177 @@ func Exponential() Option {
 //	    // perform retry operation
 //	}
 func Iter(options ...Option) iter.Seq[time.Duration] {
-	cfg := &config{
-		maxRetries: math.MaxInt,
-	}
-	Exponential()(cfg)
+	return newConfig(options...).delays(time.Time{})
+}
 
-	// Apply user options to override defaults
-	for _, opt := range options {
-		opt(cfg)
+// float64 returns a uniformly distributed random value in [0, 1) from the configured source.
+func (c *config) float64() float64 {
+	if c.rand != nil {
+		return c.rand.Float64()
 	}
+	return rand.Float64()
+}
 
+// delays returns an iterator over the delays described by c. MaxElapsedTime is measured
+// from start, or from the beginning of each iteration if start is the zero Time.
+func (c *config) delays(start time.Time) iter.Seq[time.Duration] {
 	return func(yield func(time.Duration) bool) {
-		delay := cfg.initialDelay
-		if cfg.maxDelay < cfg.initialDelay {
-			cfg.maxDelay = cfg.initialDelay
+		b := newBackoff(c, start)
+		defer b.release() // b is not shared, so the lock is not needed
+		for {
+			delay, ok := b.Next()
+			if !ok || !yield(delay) {
+				return
+			}
 		}
+	}
+}
 
-		attempt := 0
-		for attempt < cfg.maxRetries {
+// Backoff is a stateful backoff schedule for code that cannot be written as a loop over Iter,
+// such as a reconnect handler invoked from callbacks. Each call to Next returns the delay to
+// wait before the next attempt; Reset starts the schedule over, typically after a success.
+//
+// A Backoff is safe for concurrent use. Iter yields the same delays as a new Backoff built
+// from the same options.
+//
+// Example:
+//
+//	b := backoff.New(backoff.InitialDelay(time.Second), backoff.MaxDelay(time.Minute))
+//
+//	func onDisconnect() {
+//	    delay, ok := b.Next()
+//	    if !ok {
+//	        log.Printf("giving up after %d attempts", b.Attempt())
+//	        return
+//	    }
+//	    time.AfterFunc(delay, reconnect)
+//	}
+//
+//	func onConnect() {
+//	    b.Reset()
+//	}
+type Backoff struct {
+	mu       sync.Mutex
+	cfg      *config
+	strategy Strategy
+	start    time.Time
+	attempt  int
+	prev     time.Duration
+	done     bool
+}
 
-			currentDelay := delay
+// New returns a Backoff configured with the given options. If no options are provided,
+// it defaults to exponential backoff with sensible defaults, like Iter.
+// MaxElapsedTime is measured from the call to New or the last call to Reset.
+func New(options ...Option) *Backoff {
+	cfg := newConfig(options...)
+	return newBackoff(cfg, cfg.clock.Now())
+}
 
-			if cfg.jitterFactor > 0 {
-				jitterRange := float64(delay) * cfg.jitterFactor
-				jitter := (rand.Float64() - 0.5) * 2 * jitterRange
-				currentDelay = time.Duration(float64(delay) + jitter)
-			}
+// newBackoff returns a Backoff for cfg whose elapsed time is measured from start,
+// or from now if start is the zero Time.
+func newBackoff(cfg *config, start time.Time) *Backoff {
+	if start.IsZero() {
+		start = cfg.clock.Now()
+	}
+	b := &Backoff{
+		cfg:      cfg,
+		strategy: cfg.strategy(cfg),
+		start:    start,
+	}
+	if s, ok := b.strategy.(stopper); ok {
+		// Release the resources of a schedule abandoned before it ended
+		runtime.AddCleanup(b, stopper.stop, s)
+	}
+	return b
+}
 
-			if currentDelay > cfg.maxDelay {
-				currentDelay = cfg.maxDelay
-			}
+// stopper is implemented by strategies holding resources, such as the cursor of Sequence,
+// that must be released once the schedule ends or starts over.
+type stopper interface {
+	stop()
+}
 
-			if !yield(currentDelay) {
-				return
-			}
+// Next returns the delay to wait before the next attempt. It returns false once the
+// schedule is exhausted (MaxRetries, MaxElapsedTime or the Strategy ended it) and keeps
+// returning false until Reset is called.
+func (b *Backoff) Next() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delay, ok := b.next()
+	if !ok || b.done || b.attempt >= b.cfg.maxRetries {
+		b.release()
+	}
+	return delay, ok
+}
+
+// next computes the next delay of Next with b.mu held.
+func (b *Backoff) next() (time.Duration, bool) {
+	c := b.cfg
+	if b.done || b.attempt >= c.maxRetries {
+		return 0, false
+	}
+
+	delay, ok := b.strategy.Next(b.attempt, b.prev)
+	if !ok {
+		b.done = true
+		return 0, false
+	}
+	delay = max(0, min(c.maxDelay, delay))
+	b.prev = delay
+
+	currentDelay := delay
+	if c.jitter != nil {
+		currentDelay = max(0, c.jitter(delay, c.float64()))
+	}
 
-			nextDelay := time.Duration(float64(delay) * cfg.multiplier)
-			delay = min(cfg.maxDelay, nextDelay)
-			attempt++
+	if currentDelay > c.maxDelay {
+		currentDelay = c.maxDelay
+	}
+
+	if c.maxElapsed > 0 {
+		remaining := c.maxElapsed - c.clock.Now().Sub(b.start)
+		if remaining <= 0 {
+			b.done = true
+			return 0, false
+		}
+		if currentDelay >= remaining {
+			// Clamp the final delay to the remaining budget
+			currentDelay = remaining
+			b.done = true
 		}
 	}
+
+	b.attempt++
+	return currentDelay, true
+}
+
+// Reset starts the schedule over from the first delay and restarts the MaxElapsedTime budget.
+func (b *Backoff) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.release()
+	b.start = b.cfg.clock.Now()
+	b.attempt = 0
+	b.prev = 0
+	b.done = false
+}
+
+// release stops the strategy if it holds resources. It is called with b.mu held.
+func (b *Backoff) release() {
+	if s, ok := b.strategy.(stopper); ok {
+		s.stop()
+	}
+}
+
+// Attempt returns the number of delays returned by Next since the Backoff was created
+// or last reset.
+func (b *Backoff) Attempt() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attempt
 }
 
 // Retry executes a function with automatic retry logic using exponential backoff.
@@ -292,8 +716,9 @@ func Iter(options ...Option) iter.Seq[time.Duration] {
 // This is a convenience wrapper around RetryWithContext using context.Background().
 //
 // The function fn should return a value and an error. If the error is nil, the operation
-// is considered successful. If the error is a CancelError (created with Cancel()), retries
-// will stop immediately.
+// is considered successful. If the error is or wraps a CancelError (created with Cancel()),
+// retries will stop immediately and that error is returned. If the retry limit is exhausted,
+// a *RetryError recording every attempt is returned.
 //
 // Example:
 //
@@ -323,9 +748,14 @@ func Retry[T any](fn func() (T, error), options ...Option) (T, error) {
 // or the retry limit is exhausted.
 //
 // The function fn should return a value and an error. If the error is nil, the operation
-// is considered successful. If the error is a CancelError (created with Cancel()), retries
-// will stop immediately. If the context is cancelled, the function returns immediately with
-// the context error.
+// is considered successful. If the error is or wraps a CancelError (created with Cancel()),
+// retries will stop immediately and that error is returned. If the context is cancelled or
+// the retry limit is exhausted, the function returns a *RetryError recording every attempt;
+// when the context ended, errors.Is reports its error (context.Canceled or
+// context.DeadlineExceeded). Errors can also be classified as permanent with RetryIf,
+// RetryOnErrors and StopOnErrors; the classification is consulted before each delay.
+//
+// Use RetryFunc if fn needs the context or information about the current attempt.
 //
 // Example:
 //
@@ -336,38 +766,11 @@ func Retry[T any](fn func() (T, error), options ...Option) (T, error) {
 //	    return http.Get("https://api.example.com/data")
 //	}, backoff.MaxRetries(5), backoff.MaxDelay(2*time.Second))
 //
-//	if err == context.DeadlineExceeded {
+//	if errors.Is(err, context.DeadlineExceeded) {
 //	    // Operation timed out after 30 seconds
 //	}
 func RetryWithContext[T any](ctx context.Context, fn func() (T, error), options ...Option) (T, error) {
-	var lastErr error
-	var result T
-
-	result, lastErr = fn()
-	if lastErr == nil {
-		return result, nil
-	}
-
-	// Check if the initial error is a cancel error
-	if _, ok := lastErr.(CancelError); ok {
-		return result, lastErr
-	}
-
-	for delay := range Iter(options...) {
-		select {
-		case <-ctx.Done():
-			return result, ctx.Err()
-		case <-time.After(delay):
-			result, lastErr = fn()
-			if lastErr == nil {
-				return result, nil
-			}
-			// Check if the error is a cancel error and stop retrying
-			if _, ok := lastErr.(CancelError); ok {
-				return result, lastErr
-			}
-		}
-	}
-
-	return result, lastErr
+	return RetryFunc(ctx, func(context.Context, Attempt) (T, error) {
+		return fn()
+	}, options...)
 }
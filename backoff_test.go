@@ -3,6 +3,9 @@ package backoff
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
 	"testing"
 	"time"
 )
@@ -213,11 +216,15 @@ func TestRetryFailure(t *testing.T) {
 		return 0, errors.New("persistent failure")
 	}, InitialDelay(1*time.Millisecond), MaxRetries(2), JitterFactor(0))
 
-	if err == nil {
-		t.Errorf("Expected error, got nil")
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %v", err)
 	}
-	if err.Error() != "persistent failure" {
-		t.Errorf("Expected 'persistent failure', got %v", err)
+	if retryErr.Last().Error() != "persistent failure" {
+		t.Errorf("Expected 'persistent failure', got %v", retryErr.Last())
+	}
+	if len(retryErr.Attempts) != 3 {
+		t.Errorf("Expected 3 recorded attempts, got %d", len(retryErr.Attempts))
 	}
 	if result != 0 {
 		t.Errorf("Expected result 0, got %v", result)
@@ -247,35 +254,37 @@ func TestRetryImmediateSuccess(t *testing.T) {
 }
 
 func TestRetryWithContext_Cancellation(t *testing.T) {
+	clock := &instantClock{}
 	attempts := 0
 
-	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
 	defer cancel()
 
 	result, err := RetryWithContext(ctx, func() (string, error) {
 		attempts++
+		if attempts == 2 {
+			<-ctx.Done() // the deadline passes during the second attempt
+		}
 		return "", errors.New("always fails")
-	}, InitialDelay(10*time.Millisecond), MaxRetries(5))
+	}, WithClock(clock), InitialDelay(10*time.Millisecond), MaxRetries(5))
 
-	if err != context.DeadlineExceeded {
+	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Errorf("Expected DeadlineExceeded error, got %v", err)
 	}
 	if result != "" {
 		t.Errorf("Expected empty result, got %v", result)
 	}
-	// Should have made some attempts but not all 6 (initial + 5 retries)
-	if attempts == 0 {
-		t.Errorf("Expected at least 1 attempt, got %d", attempts)
-	}
-	if attempts > 6 {
-		t.Errorf("Expected at most 6 attempts, got %d", attempts)
+	// Retries stop once the deadline has passed, before all 6 attempts (initial + 5 retries)
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
 	}
 }
 
 func TestRetryWithContext_Success(t *testing.T) {
 	attempts := 0
 
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	clock := &instantClock{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 
 	result, err := RetryWithContext(ctx, func() (int, error) {
@@ -284,7 +293,7 @@ func TestRetryWithContext_Success(t *testing.T) {
 			return 0, errors.New("temporary failure")
 		}
 		return 42, nil
-	}, InitialDelay(1*time.Millisecond), MaxRetries(3))
+	}, WithClock(clock), InitialDelay(1*time.Millisecond), MaxRetries(3))
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -347,7 +356,8 @@ func TestRetryCancelErrorOnFirstAttempt(t *testing.T) {
 func TestRetryWithContextCancelError(t *testing.T) {
 	attempts := 0
 
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	clock := &instantClock{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 
 	result, err := RetryWithContext(ctx, func() (bool, error) {
@@ -356,7 +366,7 @@ func TestRetryWithContextCancelError(t *testing.T) {
 			return false, errors.New("temporary failure")
 		}
 		return false, Cancel(errors.New("context cancelled failure"))
-	}, InitialDelay(1*time.Millisecond), MaxRetries(10))
+	}, WithClock(clock), InitialDelay(1*time.Millisecond), MaxRetries(10))
 
 	if err == nil {
 		t.Errorf("Expected error, got nil")
@@ -454,6 +464,36 @@ func TestExponentialBackoff(t *testing.T) {
 	}
 }
 
+func TestConstantWithMultiplier(t *testing.T) {
+	var delays []time.Duration
+	for delay := range Iter(
+		Constant(),
+		MaxDelay(10*time.Second),
+		Multiplier(2), // A later multiplier turns the constant delay back into exponential
+		MaxRetries(5),
+	) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+	}
+
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d", len(expected), len(delays))
+	}
+
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
 func TestConstantWithCustomDelay(t *testing.T) {
 	var delays []time.Duration
 	for delay := range Iter(
@@ -479,3 +519,413 @@ func TestConstantWithCustomDelay(t *testing.T) {
 		}
 	}
 }
+
+func TestWithStrategy(t *testing.T) {
+	linear := StrategyFunc(func(attempt int, _ time.Duration) (time.Duration, bool) {
+		return time.Duration(attempt+1) * 100 * time.Millisecond, true
+	})
+
+	var delays []time.Duration
+	for delay := range Iter(
+		WithStrategy(linear),
+		MaxDelay(250*time.Millisecond),
+		JitterFactor(0),
+		MaxRetries(4),
+	) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		250 * time.Millisecond, // capped at max delay
+		250 * time.Millisecond,
+	}
+
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d", len(expected), len(delays))
+	}
+
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestWithStrategyPrevIsCapped(t *testing.T) {
+	var prevs []time.Duration
+	strategy := StrategyFunc(func(attempt int, prev time.Duration) (time.Duration, bool) {
+		prevs = append(prevs, prev)
+		return time.Second, true
+	})
+
+	for range Iter(WithStrategy(strategy), MaxDelay(500*time.Millisecond), MaxRetries(3)) {
+	}
+
+	expected := []time.Duration{0, 500 * time.Millisecond, 500 * time.Millisecond}
+	if len(prevs) != len(expected) {
+		t.Fatalf("Expected %d calls, got %d", len(expected), len(prevs))
+	}
+	for i, expectedPrev := range expected {
+		if prevs[i] != expectedPrev {
+			t.Errorf("Call %d: expected prev %v, got %v", i, expectedPrev, prevs[i])
+		}
+	}
+}
+
+func TestWithStrategyStopsEarly(t *testing.T) {
+	strategy := StrategyFunc(func(attempt int, _ time.Duration) (time.Duration, bool) {
+		return time.Millisecond, attempt < 2
+	})
+
+	count := 0
+	for range Iter(WithStrategy(strategy), MaxRetries(10)) {
+		count++
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 delays, got %d", count)
+	}
+}
+
+func TestRetryWithStrategy(t *testing.T) {
+	attempts := 0
+	strategy := StrategyFunc(func(attempt int, _ time.Duration) (time.Duration, bool) {
+		return time.Millisecond, attempt < 2
+	})
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, errors.New("always fails")
+	}, WithStrategy(strategy))
+
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if attempts != 3 { // initial attempt + 2 retries allowed by the strategy
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDecorrelatedBackoff(t *testing.T) {
+	initial := 10 * time.Millisecond
+	maxDelay := 500 * time.Millisecond
+
+	for range 100 {
+		prev := initial
+		count := 0
+		for delay := range Iter(
+			Decorrelated(),
+			InitialDelay(initial),
+			MaxDelay(maxDelay),
+			MaxRetries(10),
+		) {
+			upper := min(maxDelay, prev*3)
+			if delay < initial || delay > upper {
+				t.Fatalf("Delay %d: %v is outside expected range [%v, %v]", count, delay, initial, upper)
+			}
+			prev = delay
+			count++
+		}
+
+		if count != 10 {
+			t.Fatalf("Expected 10 delays, got %d", count)
+		}
+	}
+}
+
+func TestDecorrelatedBackoffIsRandomized(t *testing.T) {
+	seen := make(map[time.Duration]bool)
+	for range 10 {
+		for delay := range Iter(Decorrelated(), MaxRetries(1)) {
+			seen[delay] = true
+		}
+	}
+
+	if len(seen) < 2 {
+		t.Errorf("Expected decorrelated delays to vary, got %v", seen)
+	}
+}
+
+func TestRetryDecorrelated(t *testing.T) {
+	attempts := 0
+
+	result, err := Retry(func() (string, error) {
+		attempts++
+		if attempts < 3 {
+			return "", errors.New("temporary failure")
+		}
+		return "success", nil
+	}, Decorrelated(), InitialDelay(1*time.Millisecond), MaxDelay(5*time.Millisecond), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "success" {
+		t.Errorf("Expected result 'success', got %v", result)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestIsCancel(t *testing.T) {
+	base := errors.New("permanent failure")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", base, false},
+		{"cancel error", Cancel(base), true},
+		{"wrapped cancel error", fmt.Errorf("load: %w", Cancel(base)), true},
+		{"doubly wrapped cancel error", fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", Cancel(base))), true},
+		{"joined with cancel error", errors.Join(errors.New("other"), Cancel(base)), true},
+		{"joined without cancel error", errors.Join(errors.New("other"), base), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCancel(tt.err); got != tt.want {
+			t.Errorf("%s: expected IsCancel to be %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRetryWrappedCancelError(t *testing.T) {
+	attempts := 0
+	base := errors.New("permanent failure")
+
+	_, err := Retry(func() (string, error) {
+		attempts++
+		if attempts == 1 {
+			return "", errors.New("temporary failure")
+		}
+		return "", fmt.Errorf("load: %w", Cancel(base))
+	}, InitialDelay(1*time.Millisecond), MaxRetries(5), JitterFactor(0))
+
+	if !errors.Is(err, base) {
+		t.Errorf("Expected error wrapping %v, got %v", base, err)
+	}
+	if attempts != 2 { // initial attempt + one retry that fails with wrapped cancel error
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryJoinedCancelErrorOnFirstAttempt(t *testing.T) {
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, errors.Join(errors.New("cleanup failed"), Cancel(errors.New("permanent failure")))
+	}, InitialDelay(1*time.Millisecond), MaxRetries(3), JitterFactor(0))
+
+	if !IsCancel(err) {
+		t.Errorf("Expected cancel error, got %v", err)
+	}
+	if attempts != 1 { // should stop immediately without retries
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestMaxElapsedTimeDoesNotLimitFastLoops(t *testing.T) {
+	count := 0
+	for range Iter(MaxElapsedTime(time.Minute), MaxRetries(5)) {
+		count++
+	}
+
+	if count != 5 {
+		t.Errorf("Expected 5 delays, got %d", count)
+	}
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	clock := &instantClock{}
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, errors.New("always fails")
+	}, WithClock(clock), Constant(), InitialDelay(10*time.Millisecond), MaxElapsedTime(35*time.Millisecond))
+
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if attempts != 5 {
+		t.Errorf("Expected 5 attempts, got %d", attempts)
+	}
+
+	// The final delay is clamped to the remaining budget
+	expected := []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond, 5 * time.Millisecond}
+	if len(clock.sleeps) != len(expected) {
+		t.Fatalf("Expected %d sleeps, got %d: %v", len(expected), len(clock.sleeps), clock.sleeps)
+	}
+	for i, expectedSleep := range expected {
+		if clock.sleeps[i] != expectedSleep {
+			t.Errorf("Sleep %d: expected %v, got %v", i, expectedSleep, clock.sleeps[i])
+		}
+	}
+}
+
+func TestWithRandIsReproducible(t *testing.T) {
+	collect := func(options ...Option) []time.Duration {
+		var delays []time.Duration
+		for delay := range Iter(append(options, WithRand(rand.New(rand.NewPCG(1, 2))), MaxRetries(5))...) {
+			delays = append(delays, delay)
+		}
+		return delays
+	}
+
+	for name, options := range map[string][]Option{
+		"symmetric":    {JitterFactor(0.5)},
+		"full":         {Jitter(FullJitter)},
+		"decorrelated": {Decorrelated()},
+	} {
+		first := collect(options...)
+		second := collect(options...)
+
+		if len(first) != 5 || len(second) != 5 {
+			t.Fatalf("%s: expected 5 delays, got %d and %d", name, len(first), len(second))
+		}
+		for i := range first {
+			if first[i] != second[i] {
+				t.Errorf("%s: delay %d: expected %v on replay, got %v", name, i, first[i], second[i])
+			}
+		}
+	}
+}
+
+func TestWithRandNilUsesGlobalSource(t *testing.T) {
+	count := 0
+	for range Iter(WithRand(nil), Jitter(FullJitter), MaxRetries(3)) {
+		count++
+	}
+
+	if count != 3 {
+		t.Errorf("Expected 3 delays, got %d", count)
+	}
+}
+
+func TestBackoffNext(t *testing.T) {
+	b := New(InitialDelay(100*time.Millisecond), MaxDelay(300*time.Millisecond), JitterFactor(0), MaxRetries(4))
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond, // capped at max delay
+		300 * time.Millisecond,
+	}
+
+	for i, expectedDelay := range expected {
+		delay, ok := b.Next()
+		if !ok {
+			t.Fatalf("Delay %d: expected ok, got exhausted", i)
+		}
+		if delay != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delay)
+		}
+		if b.Attempt() != i+1 {
+			t.Errorf("Delay %d: expected attempt %d, got %d", i, i+1, b.Attempt())
+		}
+	}
+
+	if _, ok := b.Next(); ok {
+		t.Errorf("Expected backoff to be exhausted after MaxRetries")
+	}
+	if _, ok := b.Next(); ok {
+		t.Errorf("Expected backoff to stay exhausted until Reset")
+	}
+}
+
+func TestBackoffReset(t *testing.T) {
+	b := New(InitialDelay(100*time.Millisecond), JitterFactor(0), MaxRetries(2))
+
+	b.Next()
+	b.Next()
+	if _, ok := b.Next(); ok {
+		t.Fatalf("Expected backoff to be exhausted")
+	}
+
+	b.Reset()
+	if b.Attempt() != 0 {
+		t.Errorf("Expected attempt 0 after Reset, got %d", b.Attempt())
+	}
+
+	delay, ok := b.Next()
+	if !ok {
+		t.Fatalf("Expected backoff to restart after Reset")
+	}
+	if delay != 100*time.Millisecond {
+		t.Errorf("Expected first delay 100ms after Reset, got %v", delay)
+	}
+}
+
+func TestBackoffMatchesIter(t *testing.T) {
+	options := []Option{InitialDelay(10 * time.Millisecond), Multiplier(1.5), JitterFactor(0), MaxRetries(8)}
+
+	b := New(options...)
+	for delay := range Iter(options...) {
+		next, ok := b.Next()
+		if !ok {
+			t.Fatalf("Expected Backoff to yield as many delays as Iter")
+		}
+		if next != delay {
+			t.Errorf("Expected %v from Backoff, got %v", delay, next)
+		}
+	}
+	if _, ok := b.Next(); ok {
+		t.Errorf("Expected Backoff to be exhausted with Iter")
+	}
+}
+
+func TestBackoffMaxElapsedTimeResets(t *testing.T) {
+	clock := &instantClock{}
+	b := New(WithClock(clock), Constant(), InitialDelay(time.Minute), MaxElapsedTime(90*time.Second))
+
+	delay, _ := b.Next()
+	clock.NewTimer(delay)
+	if delay, _ = b.Next(); delay != 30*time.Second {
+		t.Errorf("Expected final delay clamped to 30s, got %v", delay)
+	}
+	clock.NewTimer(delay)
+	if _, ok := b.Next(); ok {
+		t.Errorf("Expected backoff to be exhausted after MaxElapsedTime")
+	}
+
+	b.Reset()
+	if delay, ok := b.Next(); !ok || delay != time.Minute {
+		t.Errorf("Expected Reset to restart the elapsed budget, got %v, %v", delay, ok)
+	}
+}
+
+func TestBackoffConcurrentUse(t *testing.T) {
+	b := New(InitialDelay(time.Millisecond), MaxRetries(1000))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, ok := b.Next(); !ok {
+					return
+				}
+				mu.Lock()
+				total++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if total != 1000 {
+		t.Errorf("Expected 1000 delays across goroutines, got %d", total)
+	}
+	if b.Attempt() != 1000 {
+		t.Errorf("Expected attempt 1000, got %d", b.Attempt())
+	}
+}
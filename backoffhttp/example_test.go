@@ -0,0 +1,21 @@
+package backoffhttp_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scnewma/backoff/backoffhttp"
+)
+
+func ExampleParseRetryAfter() {
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+
+	for _, value := range []string{"120", "Wed, 21 Oct 2015 07:28:30 GMT", "soon"} {
+		d, ok := backoffhttp.ParseRetryAfter(value, now)
+		fmt.Println(d, ok)
+	}
+	// Output:
+	// 2m0s true
+	// 30s true
+	// 0s false
+}
@@ -0,0 +1,92 @@
+package backoffhttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minResetEpoch separates the two meanings of X-RateLimit-Reset: values from this one
+// (2001-09-09, 1e9 seconds after the Unix epoch) are Unix timestamps, smaller values are
+// a number of seconds.
+const minResetEpoch = 1_000_000_000
+
+// ParseRetryAfter parses the value of a Retry-After header, given either as a number of
+// seconds or as an HTTP date such as "Wed, 21 Oct 2015 07:28:00 GMT", and returns the delay
+// it requests. Dates are resolved relative to now; dates in the past yield a zero delay.
+// It reports false if value is not in either format.
+//
+// Example:
+//
+//	d, ok := backoffhttp.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if d, ok := parseSeconds(value); ok {
+		return d, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(0, date.Sub(now)), true
+}
+
+// RetryAfter returns the delay requested by resp before the next request, taken from the
+// first of these headers that is present and valid:
+//
+//   - Retry-After, as a number of seconds or an HTTP date
+//   - RateLimit-Reset, as a number of seconds
+//   - X-RateLimit-Reset, as a number of seconds, or as a Unix timestamp in seconds for
+//     values from 1000000000 (as sent by GitHub, among others)
+//
+// Dates and timestamps are resolved relative to the Date header of resp when present, so
+// clock skew between client and server does not distort the delay, or to the current
+// time otherwise. It reports false if none of the headers is usable.
+//
+// Example:
+//
+//	if d, ok := backoffhttp.RetryAfter(resp); ok {
+//	    return nil, backoff.RetryAfter(fmt.Errorf("status %s", resp.Status), d)
+//	}
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	now := time.Now()
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		now = date
+	}
+
+	if d, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		return d, true
+	}
+	if d, ok := parseSeconds(strings.TrimSpace(resp.Header.Get("RateLimit-Reset"))); ok {
+		return d, true
+	}
+
+	reset := strings.TrimSpace(resp.Header.Get("X-RateLimit-Reset"))
+	seconds, err := strconv.ParseInt(reset, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	if seconds >= minResetEpoch {
+		return max(0, time.Unix(seconds, 0).Sub(now)), true
+	}
+	return secondsToDuration(seconds), true
+}
+
+// parseSeconds parses a non-negative number of seconds.
+func parseSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return secondsToDuration(seconds), true
+}
+
+// secondsToDuration converts seconds to a Duration, saturating at the maximum Duration.
+func secondsToDuration(seconds int64) time.Duration {
+	if seconds > math.MaxInt64/int64(time.Second) {
+		return math.MaxInt64
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,74 @@
+package backoffhttp_test
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/scnewma/backoff/backoffhttp"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Wed, 21 Oct 2015 07:30:00 GMT", 2 * time.Minute, true},
+		{"Wednesday, 21-Oct-15 07:28:30 GMT", 30 * time.Second, true},
+		{"Wed Oct 21 07:28:05 2015", 5 * time.Second, true},
+		{"Wed, 21 Oct 2015 07:00:00 GMT", 0, true},
+		{"99999999999999999", math.MaxInt64, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"1.5", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		d, ok := backoffhttp.ParseRetryAfter(tt.value, now)
+		if d != tt.expected || ok != tt.ok {
+			t.Errorf("ParseRetryAfter(%q): expected %v, %v, got %v, %v", tt.value, tt.expected, tt.ok, d, ok)
+		}
+	}
+}
+
+func TestRetryAfterHeaders(t *testing.T) {
+	date := "Wed, 21 Oct 2015 07:28:00 GMT"
+	reset := time.Date(2015, 10, 21, 7, 29, 0, 0, time.UTC).Unix()
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+		ok       bool
+	}{
+		{"retry-after seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second, true},
+		{"retry-after date", http.Header{"Date": {date}, "Retry-After": {"Wed, 21 Oct 2015 07:28:10 GMT"}}, 10 * time.Second, true},
+		{"ratelimit-reset", http.Header{"Ratelimit-Reset": {"20"}}, 20 * time.Second, true},
+		{"x-ratelimit-reset seconds", http.Header{"X-Ratelimit-Reset": {"45"}}, 45 * time.Second, true},
+		{"x-ratelimit-reset epoch", http.Header{"Date": {date}, "X-Ratelimit-Reset": {strconv.FormatInt(reset, 10)}}, time.Minute, true},
+		{"x-ratelimit-reset past epoch", http.Header{"Date": {date}, "X-Ratelimit-Reset": {strconv.FormatInt(reset-3600, 10)}}, 0, true},
+		{"retry-after first", http.Header{"Retry-After": {"1"}, "Ratelimit-Reset": {"2"}, "X-Ratelimit-Reset": {"3"}}, time.Second, true},
+		{"invalid retry-after falls back", http.Header{"Retry-After": {"later"}, "Ratelimit-Reset": {"2"}}, 2 * time.Second, true},
+		{"none", http.Header{}, 0, false},
+		{"invalid", http.Header{"X-Ratelimit-Reset": {"-1"}}, 0, false},
+	}
+	for _, tt := range tests {
+		d, ok := backoffhttp.RetryAfter(&http.Response{Header: tt.header})
+		if d != tt.expected || ok != tt.ok {
+			t.Errorf("%s: expected %v, %v, got %v, %v", tt.name, tt.expected, tt.ok, d, ok)
+		}
+	}
+}
+
+func TestRetryAfterDateWithoutDateHeader(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	d, ok := backoffhttp.RetryAfter(&http.Response{Header: http.Header{"Retry-After": {future}}})
+	if !ok || d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("Expected about 1h, got %v, %v", d, ok)
+	}
+}
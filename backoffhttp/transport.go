@@ -0,0 +1,188 @@
+// Package backoffhttp retries HTTP requests with the schedules of package backoff.
+//
+// Transport wraps any http.RoundTripper, so retries apply to every request made by a client
+// without changing its call sites:
+//
+//	client := &http.Client{
+//	    Transport: backoffhttp.NewTransport(http.DefaultTransport, backoff.MaxRetries(4)),
+//	}
+//	resp, err := client.Get("https://api.example.com/data")
+package backoffhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/scnewma/backoff"
+)
+
+// DefaultRetryableStatus lists the response status codes retried by a Transport whose
+// RetryableStatus is nil: 429 Too Many Requests, 502 Bad Gateway, 503 Service Unavailable
+// and 504 Gateway Timeout.
+var DefaultRetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultMaxRetries is the number of retries made by a Transport whose Options do not set
+// backoff.MaxRetries, so that a dependency that keeps answering 429 or 503 is not retried
+// forever.
+const DefaultMaxRetries = 3
+
+// maxDrain is the number of bytes read from the body of a discarded response so that its
+// connection can be reused.
+const maxDrain = 4 << 10
+
+// Transport is an http.RoundTripper that retries requests on connection errors and on
+// retryable response status codes, waiting between attempts according to Options.
+//
+// Only idempotent requests are retried: GET, HEAD, OPTIONS, TRACE, PUT and DELETE, or any
+// request with an Idempotency-Key header. Requests with a body are only retried if their
+// GetBody field is set, as it is by http.NewRequest for common body types, so the body can
+// be sent again. Other requests are made once.
+//
+// A delay requested by a retryable response through its Retry-After or rate limit headers
+// (see RetryAfter) replaces the next delay of the schedule, limited to MaxDelay and to
+// MaxElapsedTime. If it would not end before the deadline of the request context, the
+// request fails at once with an error matching context.DeadlineExceeded.
+//
+// When retries are exhausted on a retryable status code, the last response is returned
+// with a nil error so the caller can inspect it. Responses of earlier attempts are drained
+// and closed. The request context bounds all attempts and the delays between them;
+// backoff.AttemptTimeout should not be used, as the attempt context also governs reading
+// the returned response body.
+//
+// Example:
+//
+//	client := &http.Client{
+//	    Transport: &backoffhttp.Transport{
+//	        RetryableStatus: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+//	        Options:         []backoff.Option{backoff.MaxRetries(5), backoff.MaxDelay(10 * time.Second)},
+//	    },
+//	}
+type Transport struct {
+	// Base is the RoundTripper used to make each attempt. If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// RetryableStatus lists the response status codes that are retried.
+	// If nil, DefaultRetryableStatus is used.
+	RetryableStatus []int
+
+	// Options configure the delays between attempts, as for backoff.Iter. Unlike
+	// backoff.Iter, at most DefaultMaxRetries retries are made unless Options set
+	// backoff.MaxRetries.
+	Options []backoff.Option
+}
+
+// NewTransport returns a Transport that makes requests with base and waits between
+// attempts according to options. If base is nil, http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper, options ...backoff.Option) *Transport {
+	return &Transport{Base: base, Options: options}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return t.base().RoundTrip(req)
+	}
+
+	var prev *http.Response
+	resp, err := backoff.RetryFunc(req.Context(), func(ctx context.Context, a backoff.Attempt) (*http.Response, error) {
+		r := req.WithContext(ctx)
+		if a.Number > 1 {
+			discard(prev)
+			prev = nil
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, backoff.Cancel(fmt.Errorf("backoffhttp: rewind request body: %w", err))
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.base().RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(t.retryableStatus(), resp.StatusCode) {
+			return resp, nil
+		}
+
+		prev = resp
+		var statusErr error = &statusError{status: resp.Status}
+		if d, ok := RetryAfter(resp); ok {
+			statusErr = backoff.RetryAfter(statusErr, d)
+		}
+		return resp, statusErr
+	}, t.options()...)
+	if err == nil {
+		return resp, nil
+	}
+
+	// Retries were exhausted on a retryable status: hand the last response to the caller
+	var retryErr *backoff.RetryError
+	if resp != nil && errors.As(err, &retryErr) && retryErr.ContextErr == nil && retryErr.StopErr == nil {
+		return resp, nil
+	}
+	// The result is nil when a circuit breaker or retry budget stopped retries, so drain the
+	// last response
+	discard(prev)
+	return nil, err
+}
+
+// options returns Options after the default retry count, so that Options can override it.
+func (t *Transport) options() []backoff.Option {
+	return append([]backoff.Option{backoff.MaxRetries(DefaultMaxRetries)}, t.Options...)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) retryableStatus() []int {
+	if t.RetryableStatus == nil {
+		return DefaultRetryableStatus
+	}
+	return t.RetryableStatus
+}
+
+// retryable reports whether req is idempotent and its body, if any, can be sent again.
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// discard drains and closes the body of a response that will not be returned, so that
+// its connection can be reused.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	io.CopyN(io.Discard, resp.Body, maxDrain)
+	resp.Body.Close()
+}
+
+// statusError is the error recorded for an attempt that received a retryable status code.
+type statusError struct {
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "backoffhttp: unexpected status " + e.status
+}
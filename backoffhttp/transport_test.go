@@ -0,0 +1,440 @@
+package backoffhttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scnewma/backoff"
+	"github.com/scnewma/backoff/backoffhttp"
+)
+
+// instantClock is a backoff.Clock whose timers fire immediately, recording the delays.
+type instantClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *instantClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *instantClock) NewTimer(d time.Duration) backoff.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return instantTimer(ch)
+}
+
+type instantTimer chan time.Time
+
+func (t instantTimer) C() <-chan time.Time { return t }
+func (t instantTimer) Stop() bool          { return false }
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// statusServer responds with each status in turn, then with 200 OK and body "ok".
+// It records the body of every request.
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		n := len(bodies)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		if n < len(statuses) {
+			w.WriteHeader(statuses[n])
+			io.WriteString(w, "failed")
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func newClient(clock backoff.Clock, options ...backoff.Option) *http.Client {
+	options = append([]backoff.Option{backoff.WithClock(clock)}, options...)
+	return &http.Client{Transport: backoffhttp.NewTransport(nil, options...)}
+}
+
+func TestTransportRetriesStatus(t *testing.T) {
+	server, bodies := statusServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	clock := &instantClock{}
+
+	resp, err := newClient(clock, backoff.MaxRetries(5)).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("Expected 200 'ok', got %d %q", resp.StatusCode, body)
+	}
+	if len(*bodies) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(*bodies))
+	}
+	if len(clock.sleeps) != 2 {
+		t.Errorf("Expected 2 delays, got %d", len(clock.sleeps))
+	}
+}
+
+func TestTransportReturnsLastResponseWhenExhausted(t *testing.T) {
+	server, bodies := statusServer(t, 429, 429, 429, 429)
+	clock := &instantClock{}
+
+	resp, err := newClient(clock, backoff.MaxRetries(2)).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || string(body) != "failed" {
+		t.Errorf("Expected 429 'failed', got %d %q", resp.StatusCode, body)
+	}
+	if len(*bodies) != 3 {
+		t.Errorf("Expected 3 requests, got %d", len(*bodies))
+	}
+}
+
+func TestTransportDefaultMaxRetries(t *testing.T) {
+	var requests int
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     "503 Service Unavailable",
+			Body:       io.NopCloser(strings.NewReader("failed")),
+			Request:    req,
+		}, nil
+	})
+	clock := &instantClock{}
+
+	transport := &backoffhttp.Transport{Base: base, Options: []backoff.Option{backoff.WithClock(clock)}}
+	resp, err := (&http.Client{Transport: transport}).Get("http://example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", resp.StatusCode)
+	}
+	if requests != backoffhttp.DefaultMaxRetries+1 {
+		t.Errorf("Expected %d requests, got %d", backoffhttp.DefaultMaxRetries+1, requests)
+	}
+}
+
+// closeRecorder records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestTransportBreakerOpen(t *testing.T) {
+	var bodies []*closeRecorder
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := &closeRecorder{Reader: strings.NewReader("failed")}
+		bodies = append(bodies, body)
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     "503 Service Unavailable",
+			Body:       body,
+			Request:    req,
+		}, nil
+	})
+	clock := &instantClock{}
+	cb := backoff.NewCircuitBreaker(backoff.ConsecutiveFailures(2), backoff.Cooldown(backoff.WithClock(clock), backoff.InitialDelay(time.Hour)))
+
+	transport := backoffhttp.NewTransport(base, backoff.WithClock(clock), backoff.WithBreaker(cb), backoff.MaxRetries(5))
+	resp, err := (&http.Client{Transport: transport}).Get("http://example.com")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected error, got %d", resp.StatusCode)
+	}
+	if !errors.Is(err, backoff.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if !body.closed {
+			t.Errorf("Expected response %d to be closed", i+1)
+		}
+	}
+}
+
+func TestTransportDoesNotRetryOtherStatus(t *testing.T) {
+	server, bodies := statusServer(t, http.StatusInternalServerError)
+	clock := &instantClock{}
+
+	resp, err := newClient(clock).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", resp.StatusCode)
+	}
+	if len(*bodies) != 1 {
+		t.Errorf("Expected 1 request, got %d", len(*bodies))
+	}
+}
+
+func TestTransportRetryableStatus(t *testing.T) {
+	server, bodies := statusServer(t, http.StatusInternalServerError)
+	clock := &instantClock{}
+
+	client := &http.Client{Transport: &backoffhttp.Transport{
+		RetryableStatus: []int{http.StatusInternalServerError},
+		Options:         []backoff.Option{backoff.WithClock(clock)},
+	}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if len(*bodies) != 2 {
+		t.Errorf("Expected 2 requests, got %d", len(*bodies))
+	}
+}
+
+func TestTransportRetriesConnectionErrors(t *testing.T) {
+	clock := &instantClock{}
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Request: req}, nil
+	})
+
+	client := &http.Client{Transport: backoffhttp.NewTransport(base, backoff.WithClock(clock))}
+	resp, err := client.Get("http://example.invalid")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestTransportConnectionErrorsExhausted(t *testing.T) {
+	clock := &instantClock{}
+	refused := errors.New("connection refused")
+	base := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, refused
+	})
+
+	client := &http.Client{Transport: backoffhttp.NewTransport(base, backoff.WithClock(clock), backoff.MaxRetries(2))}
+	_, err := client.Get("http://example.invalid")
+	if !errors.Is(err, refused) {
+		t.Errorf("Expected error matching %v, got %v", refused, err)
+	}
+	var retryErr *backoff.RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 {
+		t.Errorf("Expected RetryError with 3 attempts, got %v", err)
+	}
+}
+
+func TestTransportRewindsBody(t *testing.T) {
+	server, bodies := statusServer(t, http.StatusServiceUnavailable)
+	clock := &instantClock{}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	resp, err := newClient(clock).Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	expected := []string{"payload", "payload"}
+	if len(*bodies) != len(expected) {
+		t.Fatalf("Expected %d requests, got %d", len(expected), len(*bodies))
+	}
+	for i, body := range expected {
+		if (*bodies)[i] != body {
+			t.Errorf("Request %d: expected body %q, got %q", i+1, body, (*bodies)[i])
+		}
+	}
+}
+
+func TestTransportDoesNotRetryNonIdempotent(t *testing.T) {
+	server, bodies := statusServer(t, http.StatusServiceUnavailable)
+	clock := &instantClock{}
+
+	resp, err := newClient(clock).Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", resp.StatusCode)
+	}
+	if len(*bodies) != 1 {
+		t.Errorf("Expected 1 request, got %d", len(*bodies))
+	}
+}
+
+func TestTransportRetriesWithIdempotencyKey(t *testing.T) {
+	server, bodies := statusServer(t, http.StatusServiceUnavailable)
+	clock := &instantClock{}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	req.Header.Set("Idempotency-Key", "8e03978e")
+	resp, err := newClient(clock).Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if len(*bodies) != 2 || (*bodies)[1] != "payload" {
+		t.Errorf("Expected body to be sent twice, got %q", *bodies)
+	}
+}
+
+func TestTransportDoesNotRetryUnrewindableBody(t *testing.T) {
+	server, bodies := statusServer(t, http.StatusServiceUnavailable)
+	clock := &instantClock{}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL, io.NopCloser(strings.NewReader("payload")))
+	resp, err := newClient(clock).Do(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if len(*bodies) != 1 {
+		t.Errorf("Expected 1 request, got %d", len(*bodies))
+	}
+}
+
+func TestTransportHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+	}))
+	defer server.Close()
+	clock := &instantClock{}
+
+	resp, err := newClient(clock, backoff.MaxDelay(time.Minute)).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if len(clock.sleeps) != 1 || clock.sleeps[0] != 7*time.Second {
+		t.Errorf("Expected a single delay of %v, got %v", 7*time.Second, clock.sleeps)
+	}
+}
+
+func TestTransportRetryAfterBeyondDeadline(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	clock := &instantClock{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := newClient(clock, backoff.MaxDelay(time.Hour)).Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected error, got %d", resp.StatusCode)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single request, got %d", calls)
+	}
+}
+
+func TestTransportContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	base := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		cancel()
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.invalid", nil)
+	client := &http.Client{Transport: backoffhttp.NewTransport(base, backoff.InitialDelay(time.Hour))}
+	_, err := client.Do(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTransportHonorsRateLimitReset(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("RateLimit-Reset", "4")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+	defer server.Close()
+	clock := &instantClock{}
+
+	resp, err := newClient(clock, backoff.MaxDelay(time.Minute)).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if len(clock.sleeps) != 1 || clock.sleeps[0] != 4*time.Second {
+		t.Errorf("Expected a single delay of %v, got %v", 4*time.Second, clock.sleeps)
+	}
+}
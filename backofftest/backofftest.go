@@ -0,0 +1,176 @@
+// Package backofftest provides utilities for testing code built on package backoff.
+//
+// FakeClock is a manually advanced backoff.Clock, so retry loops can be driven step by step
+// without real sleeps:
+//
+//	clock := backofftest.NewFakeClock(time.Time{})
+//	go func() {
+//	    result, err = backoff.Retry(fn, backoff.WithClock(clock), backoff.MaxRetries(3))
+//	    close(done)
+//	}()
+//	clock.BlockUntilWaiters(1) // the retry loop is waiting for its first delay
+//	clock.Advance(time.Second)
+//
+// AssertDelays checks the exact delays yielded by an iterator such as backoff.Iter.
+package backofftest
+
+import (
+	"iter"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scnewma/backoff"
+)
+
+// FakeClock is a backoff.Clock whose time only moves when Advance is called.
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to start. If start is the zero Time,
+// the clock starts at 2000-01-01 00:00:00 UTC.
+func NewFakeClock(start time.Time) *FakeClock {
+	if start.IsZero() {
+		start = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a Timer that fires once the clock has been advanced by at least d.
+// A timer with d <= 0 fires immediately.
+func (c *FakeClock) NewTimer(d time.Duration) backoff.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		ch:       make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Advance moves the clock forward by d and fires every timer whose deadline has passed,
+// in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	slices.SortStableFunc(c.timers, func(a, b *fakeTimer) int {
+		return a.deadline.Compare(b.deadline)
+	})
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	clear(c.timers[len(pending):])
+	c.timers = pending
+	c.cond.Broadcast()
+}
+
+// Waiters returns the number of timers that have not fired or been stopped.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntilWaiters blocks until at least n timers are waiting to fire. Use it to
+// synchronize with a retry loop running in another goroutine before calling Advance.
+func (c *FakeClock) BlockUntilWaiters(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// fakeTimer is a backoff.Timer created by a FakeClock.
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := slices.Index(c.timers, t)
+	if i < 0 {
+		return false
+	}
+	c.timers = slices.Delete(c.timers, i, i+1)
+	c.cond.Broadcast()
+	return true
+}
+
+// AssertDelays reports a test error unless seq yields exactly the delays in want.
+// At most len(want)+1 delays are read, so infinite iterators fail without hanging;
+// bound them with backoff.MaxRetries to check a prefix.
+//
+// Example:
+//
+//	backofftest.AssertDelays(t, backoff.Iter(
+//	    backoff.InitialDelay(100*time.Millisecond),
+//	    backoff.JitterFactor(0),
+//	    backoff.MaxRetries(3),
+//	), 100*time.Millisecond, 200*time.Millisecond, 400*time.Millisecond)
+func AssertDelays(t testing.TB, seq iter.Seq[time.Duration], want ...time.Duration) {
+	t.Helper()
+
+	var got []time.Duration
+	for delay := range seq {
+		got = append(got, delay)
+		if len(got) > len(want) {
+			break
+		}
+	}
+
+	if len(got) > len(want) {
+		t.Errorf("Expected %d delays, got more: %v", len(want), got)
+		return
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected %d delays, got %d: %v", len(want), len(got), got)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Delay %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package backofftest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/scnewma/backoff"
+	"github.com/scnewma/backoff/backofftest"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := backofftest.NewFakeClock(start)
+
+	short := clock.NewTimer(1 * time.Second)
+	long := clock.NewTimer(3 * time.Second)
+
+	if clock.Waiters() != 2 {
+		t.Errorf("Expected 2 waiters, got %d", clock.Waiters())
+	}
+
+	clock.Advance(2 * time.Second)
+
+	select {
+	case now := <-short.C():
+		if !now.Equal(start.Add(2 * time.Second)) {
+			t.Errorf("Expected fire time %v, got %v", start.Add(2*time.Second), now)
+		}
+	default:
+		t.Errorf("Expected short timer to fire")
+	}
+	select {
+	case <-long.C():
+		t.Errorf("Expected long timer not to fire yet")
+	default:
+	}
+
+	if clock.Waiters() != 1 {
+		t.Errorf("Expected 1 waiter, got %d", clock.Waiters())
+	}
+	if !clock.Now().Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Expected now %v, got %v", start.Add(2*time.Second), clock.Now())
+	}
+}
+
+func TestFakeClockStop(t *testing.T) {
+	clock := backofftest.NewFakeClock(time.Time{})
+
+	timer := clock.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Errorf("Expected Stop to report an active timer")
+	}
+	if timer.Stop() {
+		t.Errorf("Expected second Stop to report an inactive timer")
+	}
+
+	clock.Advance(time.Minute)
+	select {
+	case <-timer.C():
+		t.Errorf("Expected stopped timer not to fire")
+	default:
+	}
+}
+
+func TestFakeClockZeroDuration(t *testing.T) {
+	clock := backofftest.NewFakeClock(time.Time{})
+
+	select {
+	case <-clock.NewTimer(0).C():
+	default:
+		t.Errorf("Expected zero-duration timer to fire immediately")
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("Expected 0 waiters, got %d", clock.Waiters())
+	}
+}
+
+func TestRetryWithFakeClock(t *testing.T) {
+	clock := backofftest.NewFakeClock(time.Time{})
+	attempts := 0
+	done := make(chan error)
+
+	go func() {
+		_, err := backoff.Retry(func() (int, error) {
+			attempts++
+			return 0, errors.New("always fails")
+		}, backoff.WithClock(clock), backoff.InitialDelay(time.Second), backoff.JitterFactor(0), backoff.MaxRetries(2))
+		done <- err
+	}()
+
+	clock.BlockUntilWaiters(1)
+	clock.Advance(time.Second)
+	clock.BlockUntilWaiters(1)
+	clock.Advance(2 * time.Second)
+
+	err := <-done
+	var retryErr *backoff.RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %v", err)
+	}
+	if attempts != 3 { // initial attempt + 2 retries
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryWithContextCancellationWithFakeClock(t *testing.T) {
+	clock := backofftest.NewFakeClock(time.Time{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	done := make(chan error)
+
+	go func() {
+		_, err := backoff.RetryWithContext(ctx, func() (string, error) {
+			attempts++
+			return "", errors.New("always fails")
+		}, backoff.WithClock(clock), backoff.InitialDelay(10*time.Millisecond), backoff.MaxRetries(5))
+		done <- err
+	}()
+
+	// Let two retries happen, then cancel while the loop waits for the third
+	clock.BlockUntilWaiters(1)
+	clock.Advance(time.Second)
+	clock.BlockUntilWaiters(1)
+	clock.Advance(time.Second)
+	clock.BlockUntilWaiters(1)
+	cancel()
+
+	err := <-done
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Canceled error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("Expected cancelled retry loop to stop its timer, got %d waiters", clock.Waiters())
+	}
+}
+
+func TestAttemptTimeoutWithFakeClock(t *testing.T) {
+	clock := backofftest.NewFakeClock(time.Time{})
+	done := make(chan error)
+
+	go func() {
+		_, err := backoff.RetryFunc(context.Background(), func(ctx context.Context, a backoff.Attempt) (bool, error) {
+			if a.Number == 1 {
+				<-ctx.Done()
+				return false, ctx.Err()
+			}
+			return true, nil
+		}, backoff.WithClock(clock), backoff.AttemptTimeout(time.Minute), backoff.InitialDelay(time.Second))
+		done <- err
+	}()
+
+	clock.BlockUntilWaiters(1) // attempt timeout
+	clock.Advance(time.Minute)
+	clock.BlockUntilWaiters(1) // backoff delay
+	clock.Advance(time.Minute)
+
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestAssertDelays(t *testing.T) {
+	backofftest.AssertDelays(t, backoff.Iter(
+		backoff.InitialDelay(100*time.Millisecond),
+		backoff.JitterFactor(0),
+		backoff.MaxRetries(3),
+	), 100*time.Millisecond, 200*time.Millisecond, 400*time.Millisecond)
+}
+
+// recorder captures failures reported through testing.TB.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertDelaysFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		seq  []backoff.Option
+		want []time.Duration
+	}{
+		{"mismatch", []backoff.Option{backoff.Constant(), backoff.MaxRetries(2)}, []time.Duration{time.Second, 2 * time.Second}},
+		{"too few", []backoff.Option{backoff.Constant(), backoff.MaxRetries(1)}, []time.Duration{time.Second, time.Second}},
+		{"too many", []backoff.Option{backoff.Constant()}, []time.Duration{time.Second}},
+	}
+
+	for _, tt := range tests {
+		r := &recorder{TB: t}
+		backofftest.AssertDelays(r, backoff.Iter(tt.seq...), tt.want...)
+		if len(r.errors) != 1 {
+			t.Errorf("%s: expected 1 error, got %d: %v", tt.name, len(r.errors), r.errors)
+		}
+	}
+}
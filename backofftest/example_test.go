@@ -0,0 +1,40 @@
+package backofftest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/scnewma/backoff"
+	"github.com/scnewma/backoff/backofftest"
+)
+
+func ExampleFakeClock() {
+	clock := backofftest.NewFakeClock(time.Time{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		result, err := backoff.RetryFunc(context.Background(), func(_ context.Context, a backoff.Attempt) (string, error) {
+			fmt.Printf("Attempt %d after %v\n", a.Number, a.Elapsed)
+			if a.Number < 3 {
+				return "", errors.New("temporary failure")
+			}
+			return "success", nil
+		}, backoff.WithClock(clock), backoff.InitialDelay(time.Minute), backoff.JitterFactor(0))
+		fmt.Printf("Result: %s, Error: %v\n", result, err)
+	}()
+
+	// Each step waits for the retry loop to sleep, then skips the delay instantly
+	clock.BlockUntilWaiters(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntilWaiters(1)
+	clock.Advance(2 * time.Minute)
+	<-done
+	// Output:
+	// Attempt 1 after 0s
+	// Attempt 2 after 1m0s
+	// Attempt 3 after 3m0s
+	// Result: success, Error: <nil>
+}
@@ -0,0 +1,286 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by RetryFunc, RetryWithContext and Retry when the circuit
+// breaker attached with WithBreaker is open, without calling the retried function. When the
+// breaker opens after attempts have failed, it is returned wrapped in a *RetryError holding
+// their errors.
+var ErrCircuitOpen = errors.New("backoff: circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// StateClosed lets every call through while counting failures.
+	StateClosed CircuitState = iota
+
+	// StateOpen rejects every call until the cool-down period has passed.
+	StateOpen
+
+	// StateHalfOpen lets a single probe call through to decide whether to close again.
+	StateHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOption is a function that configures a CircuitBreaker.
+type BreakerOption func(*breakerConfig)
+
+type breakerConfig struct {
+	consecutiveFailures int
+	failureRatio        float64
+	window              int
+	cooldown            []Option
+}
+
+// ConsecutiveFailures trips the breaker after n failed calls in a row.
+// If n is <= 0, consecutive failures do not trip the breaker. The default is 5.
+func ConsecutiveFailures(n int) BreakerOption {
+	return func(c *breakerConfig) {
+		c.consecutiveFailures = max(0, n)
+	}
+}
+
+// FailureRatio trips the breaker when at least ratio of the last window calls failed, such
+// as 0.5 for half of them. The ratio is only checked once window calls have been recorded
+// since the breaker last closed. If ratio or window is <= 0, the ratio does not trip the
+// breaker (the default).
+//
+// Example:
+//
+//	// Open when 20 or more of the last 40 calls failed
+//	cb := backoff.NewCircuitBreaker(backoff.FailureRatio(0.5, 40))
+func FailureRatio(ratio float64, window int) BreakerOption {
+	return func(c *breakerConfig) {
+		c.failureRatio = ratio
+		c.window = max(0, window)
+	}
+}
+
+// Cooldown sets the schedule of cool-down periods the breaker stays open before letting a
+// probe call through, using the same options as Iter. Each failed probe opens the breaker
+// for the next delay of the schedule; once it is exhausted, its last delay is repeated.
+// The schedule starts over when the breaker closes.
+// The default is exponential from 1s up to 1m.
+//
+// The breaker measures time on the Clock set by WithClock among options, if any.
+//
+// Example:
+//
+//	cb := backoff.NewCircuitBreaker(backoff.Cooldown(backoff.InitialDelay(5*time.Second), backoff.MaxDelay(5*time.Minute)))
+func Cooldown(options ...Option) BreakerOption {
+	return func(c *breakerConfig) {
+		c.cooldown = options
+	}
+}
+
+// CircuitBreaker stops calls to a dependency that keeps failing, so that retries do not add
+// load to a service that is down. It starts closed and counts the outcome of every call.
+// When a threshold set by ConsecutiveFailures or FailureRatio is reached, it opens and
+// rejects calls with ErrCircuitOpen for a cool-down period. It then becomes half-open and
+// lets a single probe call through: a success closes it, a failure opens it again for
+// the next cool-down period of the schedule.
+//
+// A CircuitBreaker is attached to retry loops with WithBreaker, and is typically shared by
+// every call to the same dependency. It is safe for concurrent use.
+//
+// Example:
+//
+//	var inventory = backoff.NewCircuitBreaker(backoff.ConsecutiveFailures(10))
+//
+//	stock, err := backoff.RetryWithContext(ctx, fetchStock, backoff.WithBreaker(inventory))
+//	if errors.Is(err, backoff.ErrCircuitOpen) {
+//	    return cachedStock, nil
+//	}
+type CircuitBreaker struct {
+	cfg      breakerConfig
+	cooldown *Backoff
+	clock    Clock
+
+	mu          sync.Mutex
+	state       CircuitState
+	generation  uint64 // incremented on every state change, so stale outcomes are ignored
+	openUntil   time.Time
+	lastDelay   time.Duration
+	probing     bool
+	consecutive int
+	outcomes    []bool // ring buffer of the last window outcomes, true for failures
+	next        int
+	recorded    int
+	failures    int
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker configured by options.
+func NewCircuitBreaker(options ...BreakerOption) *CircuitBreaker {
+	cfg := breakerConfig{
+		consecutiveFailures: 5,
+		cooldown:            []Option{InitialDelay(time.Second), MaxDelay(time.Minute)},
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	cooldown := New(cfg.cooldown...)
+	cb := &CircuitBreaker{
+		cfg:      cfg,
+		cooldown: cooldown,
+		clock:    cooldown.cfg.clock,
+	}
+	if cfg.failureRatio > 0 && cfg.window > 0 {
+		cb.outcomes = make([]bool, cfg.window)
+	}
+	return cb
+}
+
+// State returns the current state of the breaker. An open breaker whose cool-down period
+// has passed is reported as half-open.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateOpen && !cb.clock.Now().Before(cb.openUntil) {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// WithBreaker attaches cb to RetryFunc, RetryWithContext and Retry. Every attempt asks cb
+// for permission first: while cb is open, retries stop and an error matching ErrCircuitOpen
+// is returned with the zero value, without calling fn. Attempts that fail with a retryable
+// error, including attempts that ran out of time under AttemptTimeout, count as failures.
+// Other outcomes count as successes, since the dependency responded, including results
+// retried by RetryWhile, except attempts interrupted by the end of the parent context,
+// which are not counted.
+//
+// Example:
+//
+//	result, err := backoff.RetryWithContext(ctx, fn, backoff.WithBreaker(cb), backoff.MaxRetries(5))
+func WithBreaker(cb *CircuitBreaker) Option {
+	return func(c *config) {
+		c.breaker = cb
+	}
+}
+
+// allow reports whether a call may proceed, and returns the generation its outcome must be
+// recorded against.
+func (cb *CircuitBreaker) allow() (uint64, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case StateOpen:
+		if cb.clock.Now().Before(cb.openUntil) {
+			return 0, false
+		}
+		cb.setState(StateHalfOpen)
+		cb.probing = true
+	case StateHalfOpen:
+		if cb.probing {
+			return 0, false
+		}
+		cb.probing = true
+	}
+	return cb.generation, true
+}
+
+// done records the outcome of a call allowed in generation. A call that neither succeeded
+// nor failed, because it was interrupted, only releases the half-open probe.
+func (cb *CircuitBreaker) done(generation uint64, success, interrupted bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	switch {
+	case cb.state == StateHalfOpen && interrupted:
+		cb.probing = false
+	case cb.state == StateHalfOpen && success:
+		cb.cooldown.Reset()
+		cb.setState(StateClosed)
+	case cb.state == StateHalfOpen:
+		cb.open()
+	case interrupted:
+	case success:
+		cb.consecutive = 0
+		cb.record(false)
+	default:
+		cb.consecutive++
+		cb.record(true)
+		if cb.tripped() {
+			cb.open()
+		}
+	}
+}
+
+// record adds an outcome to the failure ratio window.
+func (cb *CircuitBreaker) record(failed bool) {
+	if cb.outcomes == nil {
+		return
+	}
+	if cb.recorded == len(cb.outcomes) {
+		if cb.outcomes[cb.next] {
+			cb.failures--
+		}
+	} else {
+		cb.recorded++
+	}
+	cb.outcomes[cb.next] = failed
+	if failed {
+		cb.failures++
+	}
+	cb.next = (cb.next + 1) % len(cb.outcomes)
+}
+
+// tripped reports whether the recorded failures reach a threshold.
+func (cb *CircuitBreaker) tripped() bool {
+	if cb.cfg.consecutiveFailures > 0 && cb.consecutive >= cb.cfg.consecutiveFailures {
+		return true
+	}
+	return cb.outcomes != nil && cb.recorded == len(cb.outcomes) &&
+		float64(cb.failures) >= cb.cfg.failureRatio*float64(len(cb.outcomes))
+}
+
+// open opens the breaker for the next cool-down period.
+func (cb *CircuitBreaker) open() {
+	if delay, ok := cb.cooldown.Next(); ok {
+		cb.lastDelay = delay
+	}
+	cb.openUntil = cb.clock.Now().Add(cb.lastDelay)
+	cb.setState(StateOpen)
+}
+
+// setState moves the breaker to state, starting a new generation with fresh counts.
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	cb.state = state
+	cb.generation++
+	cb.probing = false
+	cb.consecutive = 0
+	cb.next, cb.recorded, cb.failures = 0, 0, 0
+}
+
+// breakerDone records the outcome of an attempt with the configured circuit breaker. It is
+// called with the error returned by the attempt, before RetryWhile classifies its result.
+func (c *config) breakerDone(ctx context.Context, generation uint64, err error, timedOut bool) {
+	interrupted := err != nil && ctx.Err() != nil
+	failed := err != nil && (timedOut || c.retryable(err))
+	c.breaker.done(generation, !failed, interrupted)
+}
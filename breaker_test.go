@@ -0,0 +1,257 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// advance moves the instant clock forward by d without recording a sleep.
+func (c *instantClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestBreaker(clock Clock, options ...BreakerOption) *CircuitBreaker {
+	cooldown := Cooldown(WithClock(clock), InitialDelay(time.Second), MaxDelay(time.Minute), JitterFactor(0))
+	return NewCircuitBreaker(append([]BreakerOption{cooldown}, options...)...)
+}
+
+// call runs a single allowed call through cb and reports whether it was allowed.
+func call(cb *CircuitBreaker, success bool) bool {
+	generation, ok := cb.allow()
+	if ok {
+		cb.done(generation, success, false)
+	}
+	return ok
+}
+
+func TestCircuitBreakerConsecutiveFailures(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(3))
+
+	call(cb, false)
+	call(cb, false)
+	call(cb, true) // a success resets the count
+	call(cb, false)
+	call(cb, false)
+	if cb.State() != StateClosed {
+		t.Fatalf("Expected breaker to stay closed, got %v", cb.State())
+	}
+
+	call(cb, false)
+	if cb.State() != StateOpen {
+		t.Fatalf("Expected breaker to open after 3 consecutive failures, got %v", cb.State())
+	}
+	if call(cb, true) {
+		t.Errorf("Expected open breaker to reject calls")
+	}
+}
+
+func TestCircuitBreakerFailureRatio(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(0), FailureRatio(0.5, 4))
+
+	for _, success := range []bool{true, false, true} {
+		call(cb, success)
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("Expected breaker to stay closed until the window is full, got %v", cb.State())
+	}
+
+	call(cb, true)
+	call(cb, true) // the window slides: F, T, T, T
+	if cb.State() != StateClosed {
+		t.Fatalf("Expected breaker to stay closed below the ratio, got %v", cb.State())
+	}
+
+	call(cb, false) // T, T, T, F
+	call(cb, false) // T, T, F, F
+	if cb.State() != StateOpen {
+		t.Errorf("Expected breaker to open at a failure ratio of 0.5, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(1))
+
+	call(cb, false)
+	clock.advance(999 * time.Millisecond)
+	if call(cb, true) {
+		t.Fatalf("Expected breaker to reject calls during the cool-down")
+	}
+
+	clock.advance(time.Millisecond)
+	if cb.State() != StateHalfOpen {
+		t.Fatalf("Expected breaker to be half-open after the cool-down, got %v", cb.State())
+	}
+
+	generation, ok := cb.allow()
+	if !ok {
+		t.Fatalf("Expected half-open breaker to allow a probe")
+	}
+	if call(cb, true) {
+		t.Errorf("Expected half-open breaker to allow a single probe")
+	}
+
+	cb.done(generation, true, false)
+	if cb.State() != StateClosed {
+		t.Errorf("Expected successful probe to close the breaker, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreakerCooldownSchedule(t *testing.T) {
+	clock := &instantClock{}
+	cb := NewCircuitBreaker(
+		ConsecutiveFailures(1),
+		Cooldown(WithClock(clock), Schedule(time.Second, 2*time.Second)),
+	)
+
+	call(cb, false)
+	expected := []time.Duration{time.Second, 2 * time.Second, 2 * time.Second}
+	for i, cooldown := range expected {
+		clock.advance(cooldown - time.Millisecond)
+		if cb.State() != StateOpen {
+			t.Fatalf("Cool-down %d: expected breaker open before %v, got %v", i, cooldown, cb.State())
+		}
+		clock.advance(time.Millisecond)
+		if !call(cb, false) {
+			t.Fatalf("Cool-down %d: expected a probe after %v", i, cooldown)
+		}
+	}
+
+	// A successful probe starts the schedule over
+	clock.advance(2 * time.Second)
+	call(cb, true)
+	call(cb, false)
+	clock.advance(time.Second)
+	if cb.State() != StateHalfOpen {
+		t.Errorf("Expected cool-down schedule to restart after closing, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreakerInterruptedProbe(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(1))
+
+	call(cb, false)
+	clock.advance(time.Second)
+	generation, _ := cb.allow()
+	cb.done(generation, false, true)
+
+	if cb.State() != StateHalfOpen {
+		t.Errorf("Expected interrupted probe to leave the breaker half-open, got %v", cb.State())
+	}
+	if !call(cb, true) {
+		t.Errorf("Expected another probe after an interrupted one")
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleOutcomes(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(1))
+
+	stale, _ := cb.allow()
+	call(cb, false)
+	clock.advance(time.Second)
+	cb.allow() // probe in flight
+
+	cb.done(stale, true, false)
+	if cb.State() != StateHalfOpen {
+		t.Errorf("Expected outcome of a call from before the breaker opened to be ignored, got %v", cb.State())
+	}
+}
+
+func TestRetryWithBreaker(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(2))
+	calls := 0
+
+	_, err := RetryFunc(context.Background(), func(context.Context, Attempt) (int, error) {
+		calls++
+		return 0, errTemporary
+	}, WithBreaker(cb), WithClock(clock), MaxRetries(5))
+
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 {
+		t.Errorf("Expected RetryError with 2 attempts, got %v", err)
+	}
+	if !errors.Is(err, errTemporary) {
+		t.Errorf("Expected the attempt errors to be wrapped, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls before the breaker opened, got %d", calls)
+	}
+
+	_, err = RetryFunc(context.Background(), func(context.Context, Attempt) (int, error) {
+		calls++
+		return 1, nil
+	}, WithBreaker(cb), WithClock(clock))
+
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected fn not to be called while the breaker is open, got %d calls", calls)
+	}
+}
+
+func TestRetryWithBreakerCountsOnlyRetryableErrors(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(1))
+	notFound := errors.New("not found")
+
+	for _, fn := range []func(context.Context, Attempt) (int, error){
+		func(context.Context, Attempt) (int, error) { return 0, Cancel(errors.New("bad request")) },
+		func(context.Context, Attempt) (int, error) { return 0, notFound },
+	} {
+		RetryFunc(context.Background(), fn, WithBreaker(cb), WithClock(clock), StopOnErrors(notFound))
+	}
+
+	if cb.State() != StateClosed {
+		t.Errorf("Expected non-retryable errors not to open the breaker, got %v", cb.State())
+	}
+}
+
+func TestRetryWithBreakerCountsPendingResultsAsSuccesses(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(1))
+	calls := 0
+
+	_, err := RetryFunc(context.Background(), func(context.Context, Attempt) (string, error) {
+		calls++
+		return "PENDING", nil
+	}, WithBreaker(cb), WithClock(clock), MaxRetries(3), RetryWhile(func(status string, err error) bool {
+		return status == "PENDING"
+	}))
+
+	if !errors.Is(err, ErrConditionNotMet) {
+		t.Errorf("Expected ErrConditionNotMet, got %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", calls)
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("Expected pending results not to open the breaker, got %v", cb.State())
+	}
+}
+
+func TestCircuitStateString(t *testing.T) {
+	expected := map[CircuitState]string{
+		StateClosed:      "closed",
+		StateOpen:        "open",
+		StateHalfOpen:    "half-open",
+		CircuitState(42): "unknown",
+	}
+	for state, name := range expected {
+		if state.String() != name {
+			t.Errorf("Expected %q, got %q", name, state.String())
+		}
+	}
+}
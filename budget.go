@@ -0,0 +1,174 @@
+package backoff
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by RetryFunc, RetryWithContext and Retry when an attempt
+// fails and the retry budget attached with WithBudget allows no more retries. It is
+// returned wrapped in a *RetryError holding the errors of the failed attempts.
+var ErrBudgetExhausted = errors.New("backoff: retry budget exhausted")
+
+// budgetBuckets is the number of buckets the sliding window of a RetryBudget is divided
+// into. Counts expire one bucket at a time.
+const budgetBuckets = 10
+
+// BudgetOption is a function that configures a RetryBudget.
+type BudgetOption func(*budgetConfig)
+
+type budgetConfig struct {
+	ratio        float64
+	minPerSecond float64
+	window       time.Duration
+	clock        Clock
+}
+
+// RetryRatio sets the number of retries allowed per request, such as 0.1 for retries to
+// add at most 10% to the load of the requests. If ratio is < 0, it defaults to 0.
+// The default is 0.1.
+func RetryRatio(ratio float64) BudgetOption {
+	return func(c *budgetConfig) {
+		c.ratio = max(0, ratio)
+	}
+}
+
+// MinRetriesPerSecond sets the number of retries per second allowed regardless of the
+// number of requests, so that callers making few requests can still retry.
+// If n is < 0, it defaults to 0. The default is 10.
+func MinRetriesPerSecond(n float64) BudgetOption {
+	return func(c *budgetConfig) {
+		c.minPerSecond = max(0, n)
+	}
+}
+
+// BudgetWindow sets the duration of the sliding window over which requests and retries
+// are counted. If d is <= 0, it defaults to 10 seconds.
+func BudgetWindow(d time.Duration) BudgetOption {
+	return func(c *budgetConfig) {
+		if d <= 0 {
+			d = 10 * time.Second
+		}
+		c.window = d
+	}
+}
+
+// BudgetClock sets the Clock the sliding window is measured on.
+// If c is nil, the real clock is used (the default).
+func BudgetClock(c Clock) BudgetOption {
+	return func(cfg *budgetConfig) {
+		if c == nil {
+			c = realClock{}
+		}
+		cfg.clock = c
+	}
+}
+
+// RetryBudget limits the retries made by many retry loops together, so that retries
+// cannot multiply the load on a dependency during an outage. Over a sliding window, the
+// retries may not exceed a ratio of the requests plus a minimum number per second:
+// with the defaults, 10% of the requests plus 10 retries per second over 10 seconds.
+//
+// Every call to RetryFunc, RetryWithContext or Retry with the budget attached through
+// WithBudget counts as one request, and each of its attempts after the first as one retry.
+// When an attempt fails and the budget allows no more retries, an error matching both
+// ErrBudgetExhausted and the errors of the failed attempts is returned. A RetryBudget is safe for concurrent use.
+//
+// Example:
+//
+//	var budget = backoff.NewRetryBudget(backoff.RetryRatio(0.2))
+//
+//	// In every request handler
+//	result, err := backoff.RetryWithContext(ctx, fn, backoff.WithBudget(budget), backoff.MaxRetries(10))
+type RetryBudget struct {
+	cfg   budgetConfig
+	epoch time.Time
+
+	mu      sync.Mutex
+	buckets [budgetBuckets]budgetBucket
+}
+
+// budgetBucket counts the requests and retries of a slot of the sliding window.
+type budgetBucket struct {
+	slot     int64
+	requests int
+	retries  int
+}
+
+// NewRetryBudget returns a RetryBudget configured by options.
+func NewRetryBudget(options ...BudgetOption) *RetryBudget {
+	cfg := budgetConfig{
+		ratio:        0.1,
+		minPerSecond: 10,
+		window:       10 * time.Second,
+		clock:        realClock{},
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+	return &RetryBudget{cfg: cfg, epoch: cfg.clock.Now()}
+}
+
+// WithBudget attaches b to RetryFunc, RetryWithContext and Retry. Each call counts as a
+// request, and a failed attempt is only retried if b allows another retry; otherwise an
+// error matching ErrBudgetExhausted is returned with the zero value.
+//
+// Example:
+//
+//	result, err := backoff.Retry(fn, backoff.WithBudget(budget))
+//	if errors.Is(err, backoff.ErrBudgetExhausted) {
+//	    // too many callers are retrying: the dependency is likely down
+//	}
+func WithBudget(b *RetryBudget) Option {
+	return func(c *config) {
+		c.budget = b
+	}
+}
+
+// request records a request.
+func (b *RetryBudget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bucket(b.cfg.clock.Now()).requests++
+}
+
+// withdraw records a retry and reports true if the budget allows it.
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.cfg.clock.Now()
+	current := b.slot(now)
+	var requests, retries int
+	for _, bucket := range b.buckets {
+		if current-bucket.slot < budgetBuckets {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+
+	allowed := b.cfg.minPerSecond*b.cfg.window.Seconds() + b.cfg.ratio*float64(requests)
+	if float64(retries+1) > allowed {
+		return false
+	}
+	b.bucket(now).retries++
+	return true
+}
+
+// slot returns the index of the bucket-sized slot of time containing now.
+func (b *RetryBudget) slot(now time.Time) int64 {
+	width := max(1, b.cfg.window/budgetBuckets)
+	return int64(max(0, now.Sub(b.epoch)) / width)
+}
+
+// bucket returns the bucket counting now, clearing it if it last counted an expired slot.
+func (b *RetryBudget) bucket(now time.Time) *budgetBucket {
+	slot := b.slot(now)
+	bucket := &b.buckets[slot%budgetBuckets]
+	if bucket.slot != slot {
+		*bucket = budgetBucket{slot: slot}
+	}
+	return bucket
+}
@@ -0,0 +1,151 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withdrawals returns the number of retries b allows before it is exhausted, up to limit.
+func withdrawals(b *RetryBudget, limit int) int {
+	for i := range limit {
+		if !b.withdraw() {
+			return i
+		}
+	}
+	return limit
+}
+
+func TestRetryBudgetMinRetriesPerSecond(t *testing.T) {
+	clock := &instantClock{}
+	b := NewRetryBudget(BudgetClock(clock), RetryRatio(0), MinRetriesPerSecond(1), BudgetWindow(10*time.Second))
+
+	if n := withdrawals(b, 100); n != 10 {
+		t.Errorf("Expected 10 retries allowed by the floor, got %d", n)
+	}
+}
+
+func TestRetryBudgetRatio(t *testing.T) {
+	clock := &instantClock{}
+	b := NewRetryBudget(BudgetClock(clock), RetryRatio(0.1), MinRetriesPerSecond(0))
+
+	for range 25 {
+		b.request()
+	}
+	if n := withdrawals(b, 100); n != 2 {
+		t.Errorf("Expected 2 retries for 25 requests, got %d", n)
+	}
+
+	for range 10 {
+		b.request()
+	}
+	if n := withdrawals(b, 100); n != 1 {
+		t.Errorf("Expected 1 more retry after 10 more requests, got %d", n)
+	}
+}
+
+func TestRetryBudgetWindowSlides(t *testing.T) {
+	clock := &instantClock{}
+	b := NewRetryBudget(BudgetClock(clock), RetryRatio(0), MinRetriesPerSecond(0.5), BudgetWindow(10*time.Second))
+
+	withdrawals(b, 3)
+	clock.advance(5 * time.Second)
+	withdrawals(b, 2)
+	if b.withdraw() {
+		t.Fatalf("Expected budget to be exhausted")
+	}
+
+	// The first 3 retries expire, the last 2 are still counted
+	clock.advance(5 * time.Second)
+	if n := withdrawals(b, 100); n != 3 {
+		t.Errorf("Expected 3 retries once the oldest expired, got %d", n)
+	}
+
+	clock.advance(time.Hour)
+	if n := withdrawals(b, 100); n != 5 {
+		t.Errorf("Expected the full budget after the window passed, got %d", n)
+	}
+}
+
+func TestRetryWithBudget(t *testing.T) {
+	clock := &instantClock{}
+	b := NewRetryBudget(BudgetClock(clock), RetryRatio(0), MinRetriesPerSecond(0.2), BudgetWindow(10*time.Second))
+	calls := 0
+	fn := func(context.Context, Attempt) (int, error) {
+		calls++
+		return 1, errTemporary
+	}
+
+	result, err := RetryFunc(context.Background(), fn, WithBudget(b), WithClock(clock), MaxRetries(5))
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted, got %v", err)
+	}
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 {
+		t.Errorf("Expected RetryError with 3 attempts, got %v", err)
+	}
+	if !errors.Is(err, errTemporary) {
+		t.Errorf("Expected the attempt errors to be wrapped, got %v", err)
+	}
+	if result != 0 {
+		t.Errorf("Expected zero result, got %d", result)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+
+	calls = 0
+	_, err = RetryFunc(context.Background(), fn, WithBudget(b), WithClock(clock), MaxRetries(5))
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single call once the budget is exhausted, got %d", calls)
+	}
+}
+
+func TestRetryWithBudgetSuccessDoesNotWithdraw(t *testing.T) {
+	clock := &instantClock{}
+	b := NewRetryBudget(BudgetClock(clock), RetryRatio(0), MinRetriesPerSecond(0.1), BudgetWindow(10*time.Second))
+
+	for range 5 {
+		_, err := RetryFunc(context.Background(), func(context.Context, Attempt) (int, error) {
+			return 1, nil
+		}, WithBudget(b), WithClock(clock))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if !b.withdraw() {
+		t.Errorf("Expected successful calls to leave the budget untouched")
+	}
+}
+
+func TestRetryBudgetConcurrentUse(t *testing.T) {
+	b := NewRetryBudget(RetryRatio(0), MinRetriesPerSecond(10), BudgetWindow(10*time.Second))
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				b.request()
+				if b.withdraw() {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 100 {
+		t.Errorf("Expected 100 retries allowed, got %d", allowed)
+	}
+}
@@ -0,0 +1,145 @@
+package backoff
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passage of time so that retry loops can be tested without real sleeps.
+// The default Clock uses the time package; tests can substitute a fake implementation, such as
+// backofftest.FakeClock, with WithClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a Timer that sends the current time on its channel after at least d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event timer created by a Clock, mirroring time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered when the timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer already fired
+	// or was stopped.
+	Stop() bool
+}
+
+// WithClock sets the Clock used for delays, elapsed time and attempt timeouts.
+// If c is nil, the real clock is used (the default).
+//
+// Example:
+//
+//	// In tests, a fake clock lets retry loops run without real sleeps
+//	clock := backofftest.NewFakeClock(time.Time{})
+//	result, err := backoff.Retry(fn, backoff.WithClock(clock), backoff.MaxRetries(5))
+func WithClock(c Clock) Option {
+	return func(cfg *config) {
+		if c == nil {
+			c = realClock{}
+		}
+		cfg.clock = c
+	}
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// sleep waits for d on clock, returning early with false if ctx is done first.
+func sleep(ctx context.Context, clock Clock, d time.Duration) bool {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
+
+// withTimeout is like context.WithTimeout, but measures the timeout on clock.
+func withTimeout(parent context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(parent, d)
+	}
+
+	ctx := &timeoutCtx{
+		Context:  parent,
+		deadline: clock.Now().Add(d),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	if deadline, ok := parent.Deadline(); ok && deadline.Before(ctx.deadline) {
+		ctx.deadline = deadline
+	}
+
+	timer := clock.NewTimer(d)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			ctx.finish(context.DeadlineExceeded)
+		case <-parent.Done():
+			ctx.finish(parent.Err())
+		case <-ctx.stop:
+			ctx.finish(context.Canceled)
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() { close(ctx.stop) })
+		<-ctx.done
+	}
+}
+
+// timeoutCtx is a context whose deadline is driven by a Clock other than the real one.
+type timeoutCtx struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+	stop     chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *timeoutCtx) finish(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func (c *timeoutCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *timeoutCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *timeoutCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
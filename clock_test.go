@@ -0,0 +1,147 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// instantClock is a Clock whose timers fire immediately, advancing the current time
+// by the requested duration.
+type instantClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *instantClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *instantClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return instantTimer(ch)
+}
+
+type instantTimer chan time.Time
+
+func (t instantTimer) C() <-chan time.Time { return t }
+func (t instantTimer) Stop() bool          { return false }
+
+func TestRetryWithClock(t *testing.T) {
+	clock := &instantClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var elapsed []time.Duration
+
+	start := time.Now()
+	_, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (int, error) {
+		elapsed = append(elapsed, a.Elapsed)
+		return 0, errors.New("always fails")
+	}, WithClock(clock), InitialDelay(time.Hour), MaxDelay(10*time.Hour), JitterFactor(0), MaxRetries(3))
+
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if real := time.Since(start); real > time.Second {
+		t.Errorf("Expected retries to run without real sleeps, took %v", real)
+	}
+
+	expectedSleeps := []time.Duration{time.Hour, 2 * time.Hour, 4 * time.Hour}
+	if len(clock.sleeps) != len(expectedSleeps) {
+		t.Fatalf("Expected %d sleeps, got %d", len(expectedSleeps), len(clock.sleeps))
+	}
+	for i, expected := range expectedSleeps {
+		if clock.sleeps[i] != expected {
+			t.Errorf("Sleep %d: expected %v, got %v", i, expected, clock.sleeps[i])
+		}
+	}
+
+	expectedElapsed := []time.Duration{0, time.Hour, 3 * time.Hour, 7 * time.Hour}
+	for i, expected := range expectedElapsed {
+		if elapsed[i] != expected {
+			t.Errorf("Attempt %d: expected elapsed %v, got %v", i+1, expected, elapsed[i])
+		}
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %v", err)
+	}
+	if !retryErr.Attempts[3].Start.Equal(clock.now) {
+		t.Errorf("Expected last attempt to start at %v, got %v", clock.now, retryErr.Attempts[3].Start)
+	}
+}
+
+func TestIterMaxElapsedTimeWithClock(t *testing.T) {
+	clock := &instantClock{}
+
+	var delays []time.Duration
+	for delay := range Iter(
+		WithClock(clock),
+		Constant(),
+		InitialDelay(time.Minute),
+		MaxElapsedTime(150*time.Second),
+	) {
+		delays = append(delays, delay)
+		clock.NewTimer(delay) // advance the clock as if sleeping
+	}
+
+	expected := []time.Duration{time.Minute, time.Minute, 30 * time.Second}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d: %v", len(expected), len(delays), delays)
+	}
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestAttemptTimeoutWithClock(t *testing.T) {
+	clock := &instantClock{}
+	attempts := 0
+
+	result, err := RetryFunc(context.Background(), func(ctx context.Context, a Attempt) (string, error) {
+		attempts++
+		if a.Number == 1 {
+			// The instant clock fires the attempt timer right away
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "success", nil
+	}, WithClock(clock), AttemptTimeout(time.Hour), MaxRetries(3))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "success" {
+		t.Errorf("Expected result 'success', got %v", result)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestWithTimeoutOnClock(t *testing.T) {
+	clock := &instantClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	ctx, cancel := withTimeout(context.Background(), clock, time.Minute)
+	defer cancel()
+
+	<-ctx.Done()
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", ctx.Err())
+	}
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(time.Date(2025, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Errorf("Expected deadline one minute after start, got %v", deadline)
+	}
+}
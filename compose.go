@@ -0,0 +1,164 @@
+package backoff
+
+import (
+	"iter"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Then returns an iterator that yields every delay of each sequence in turn, moving on to
+// the next sequence once the previous one ends. Every sequence except the last should be
+// finite, for example by bounding it with MaxRetries or Take.
+//
+// Example:
+//
+//	// 3 quick retries at 50ms, then exponential from 1s up to 1m, then every 5m forever
+//	delays := backoff.Then(
+//	    backoff.Iter(backoff.Constant(), backoff.InitialDelay(50*time.Millisecond), backoff.MaxRetries(3)),
+//	    backoff.Iter(backoff.InitialDelay(time.Second), backoff.MaxDelay(time.Minute), backoff.MaxRetries(6)),
+//	    backoff.Iter(backoff.Constant(), backoff.InitialDelay(5*time.Minute)),
+//	)
+func Then(seqs ...iter.Seq[time.Duration]) iter.Seq[time.Duration] {
+	return func(yield func(time.Duration) bool) {
+		for _, seq := range seqs {
+			for delay := range seq {
+				if !yield(delay) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Take returns an iterator that yields at most the first n delays of seq.
+// If n is <= 0, no delays are yielded.
+//
+// Example:
+//
+//	delays := backoff.Take(backoff.Iter(backoff.Fibonacci()), 5)
+func Take(seq iter.Seq[time.Duration], n int) iter.Seq[time.Duration] {
+	return func(yield func(time.Duration) bool) {
+		if n <= 0 {
+			return
+		}
+		taken := 0
+		for delay := range seq {
+			if !yield(delay) {
+				return
+			}
+			taken++
+			if taken >= n {
+				return
+			}
+		}
+	}
+}
+
+// Cap returns an iterator that yields the delays of seq, limited to at most maxDelay.
+//
+// Example:
+//
+//	delays := backoff.Cap(backoff.Then(fast, slow), time.Minute)
+func Cap(seq iter.Seq[time.Duration], maxDelay time.Duration) iter.Seq[time.Duration] {
+	return func(yield func(time.Duration) bool) {
+		for delay := range seq {
+			if !yield(min(delay, maxDelay)) {
+				return
+			}
+		}
+	}
+}
+
+// Scale returns an iterator that yields the delays of seq multiplied by factor.
+// If factor is < 0, it defaults to 0.
+//
+// Example:
+//
+//	// Run a production schedule 1000x faster in an integration test
+//	delays := backoff.Scale(productionDelays, 0.001)
+func Scale(seq iter.Seq[time.Duration], factor float64) iter.Seq[time.Duration] {
+	factor = max(0, factor)
+	return func(yield func(time.Duration) bool) {
+		for delay := range seq {
+			scaled := float64(delay) * factor
+			if scaled >= math.MaxInt64 {
+				scaled = math.MaxInt64
+			}
+			if !yield(time.Duration(scaled)) {
+				return
+			}
+		}
+	}
+}
+
+// AddJitter returns an iterator that yields the delays of seq randomized by mode,
+// using the global generator from math/rand/v2. A nil mode yields the delays unchanged.
+//
+// Example:
+//
+//	delays := backoff.AddJitter(backoff.Then(fast, slow), backoff.FullJitter)
+func AddJitter(seq iter.Seq[time.Duration], mode JitterMode) iter.Seq[time.Duration] {
+	return func(yield func(time.Duration) bool) {
+		for delay := range seq {
+			if mode != nil {
+				delay = max(0, mode(delay, rand.Float64()))
+			}
+			if !yield(delay) {
+				return
+			}
+		}
+	}
+}
+
+// Sequence returns an Option that takes delays from seq, such as a schedule composed with
+// Then, Take, Cap, Scale and AddJitter, so it can drive Retry, RetryWithContext, New and
+// NewTicker. The delays are used as yielded: MaxDelay and jitter are disabled unless
+// configured by later options, while MaxRetries and MaxElapsedTime still apply.
+//
+// seq is iterated once per schedule: a Backoff pulls its delays from seq as they are
+// needed, and iterates seq again from its start when it is reset.
+//
+// Example:
+//
+//	delays := backoff.Then(
+//	    backoff.Iter(backoff.Constant(), backoff.InitialDelay(50*time.Millisecond), backoff.MaxRetries(3)),
+//	    backoff.Iter(backoff.InitialDelay(time.Second), backoff.MaxDelay(time.Minute)),
+//	)
+//	result, err := backoff.Retry(fn, backoff.Sequence(delays), backoff.MaxRetries(20))
+func Sequence(seq iter.Seq[time.Duration]) Option {
+	return func(c *config) {
+		c.maxDelay = math.MaxInt64
+		c.jitter = nil
+		c.strategy = func(*config) Strategy {
+			return &sequence{seq: seq}
+		}
+	}
+}
+
+// sequence is a Strategy that pulls the delays of seq one at a time.
+type sequence struct {
+	seq  iter.Seq[time.Duration]
+	next func() (time.Duration, bool)
+	halt func()
+}
+
+func (s *sequence) Next(attempt int, _ time.Duration) (time.Duration, bool) {
+	if attempt == 0 || s.next == nil {
+		s.stop()
+		s.next, s.halt = iter.Pull(s.seq)
+	}
+	delay, ok := s.next()
+	if !ok {
+		s.stop()
+	}
+	return delay, ok
+}
+
+// stop releases the iteration of seq in progress, if any.
+func (s *sequence) stop() {
+	if s.halt != nil {
+		s.halt()
+		s.next, s.halt = nil, nil
+	}
+}
@@ -0,0 +1,186 @@
+package backoff
+
+import (
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+// constants returns an Iter yielding n delays of d.
+func constants(d time.Duration, n int) iter.Seq[time.Duration] {
+	return Iter(Constant(), InitialDelay(d), MaxRetries(n))
+}
+
+func assertSeq(t *testing.T, seq iter.Seq[time.Duration], expected []time.Duration) {
+	t.Helper()
+
+	delays := slices.Collect(seq)
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d: %v", len(expected), len(delays), delays)
+	}
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestThen(t *testing.T) {
+	seq := Then(
+		constants(50*time.Millisecond, 3),
+		Iter(InitialDelay(time.Second), MaxDelay(4*time.Second), JitterFactor(0), MaxRetries(4)),
+		constants(5*time.Minute, 2),
+	)
+
+	assertSeq(t, seq, []time.Duration{
+		50 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond,
+		time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second,
+		5 * time.Minute, 5 * time.Minute,
+	})
+}
+
+func TestThenStopsEarly(t *testing.T) {
+	count := 0
+	for range Then(constants(time.Millisecond, 3), constants(time.Second, 3)) {
+		count++
+		if count == 4 {
+			break
+		}
+	}
+
+	if count != 4 {
+		t.Errorf("Expected 4 delays, got %d", count)
+	}
+}
+
+func TestTake(t *testing.T) {
+	assertSeq(t, Take(Iter(Constant()), 3), []time.Duration{time.Second, time.Second, time.Second})
+	assertSeq(t, Take(constants(time.Second, 2), 5), []time.Duration{time.Second, time.Second})
+	assertSeq(t, Take(Iter(Constant()), 0), nil)
+}
+
+func TestCap(t *testing.T) {
+	seq := Cap(Iter(InitialDelay(time.Second), MaxDelay(time.Hour), JitterFactor(0), MaxRetries(4)), 3*time.Second)
+
+	assertSeq(t, seq, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second})
+}
+
+func TestScale(t *testing.T) {
+	seq := Scale(Iter(InitialDelay(time.Second), JitterFactor(0), MaxRetries(3)), 0.5)
+	assertSeq(t, seq, []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second})
+
+	assertSeq(t, Scale(constants(time.Second, 2), -1), []time.Duration{0, 0})
+}
+
+func TestAddJitter(t *testing.T) {
+	for range 100 {
+		for delay := range AddJitter(constants(100*time.Millisecond, 3), EqualJitter) {
+			if delay < 50*time.Millisecond || delay >= 100*time.Millisecond {
+				t.Fatalf("Delay %v is outside expected range [50ms, 100ms)", delay)
+			}
+		}
+	}
+
+	assertSeq(t, AddJitter(constants(time.Second, 2), nil), []time.Duration{time.Second, time.Second})
+}
+
+func TestSequence(t *testing.T) {
+	seq := Then(constants(50*time.Millisecond, 2), constants(time.Minute, 2))
+
+	// Neither the default 30s MaxDelay nor the default jitter apply
+	assertSeq(t, Iter(Sequence(seq)), []time.Duration{
+		50 * time.Millisecond, 50 * time.Millisecond, time.Minute, time.Minute,
+	})
+
+	// MaxRetries still applies
+	assertSeq(t, Iter(Sequence(seq), MaxRetries(3)), []time.Duration{
+		50 * time.Millisecond, 50 * time.Millisecond, time.Minute,
+	})
+}
+
+func TestRetryWithSequence(t *testing.T) {
+	clock := &instantClock{}
+	attempts := 0
+
+	seq := Then(
+		constants(50*time.Millisecond, 3),
+		Iter(InitialDelay(time.Second), MaxDelay(time.Minute), JitterFactor(0), MaxRetries(3)),
+		Iter(Constant(), InitialDelay(5*time.Minute)),
+	)
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, errTemporary
+	}, WithClock(clock), Sequence(seq), MaxRetries(8))
+
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if attempts != 9 {
+		t.Errorf("Expected 9 attempts, got %d", attempts)
+	}
+
+	expected := []time.Duration{
+		50 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond,
+		time.Second, 2 * time.Second, 4 * time.Second,
+		5 * time.Minute, 5 * time.Minute,
+	}
+	if len(clock.sleeps) != len(expected) {
+		t.Fatalf("Expected %d sleeps, got %d", len(expected), len(clock.sleeps))
+	}
+	for i, expectedDelay := range expected {
+		if clock.sleeps[i] != expectedDelay {
+			t.Errorf("Sleep %d: expected %v, got %v", i, expectedDelay, clock.sleeps[i])
+		}
+	}
+}
+
+func TestSequenceIteratesOnce(t *testing.T) {
+	starts := 0
+	seq := func(yield func(time.Duration) bool) {
+		starts++
+		for delay := range constants(time.Second, 3) {
+			if !yield(delay) {
+				return
+			}
+		}
+	}
+
+	b := New(Sequence(seq))
+	for range 3 {
+		if _, ok := b.Next(); !ok {
+			t.Fatalf("Expected a delay")
+		}
+	}
+	if _, ok := b.Next(); ok {
+		t.Errorf("Expected the schedule to be exhausted")
+	}
+	if starts != 1 {
+		t.Errorf("Expected seq to be iterated once, got %d", starts)
+	}
+
+	// Reset iterates seq again from its start
+	b.Reset()
+	if delay, ok := b.Next(); !ok || delay != time.Second {
+		t.Errorf("Expected 1s after Reset, got %v, %v", delay, ok)
+	}
+	if starts != 2 {
+		t.Errorf("Expected seq to be iterated twice, got %d", starts)
+	}
+}
+
+func TestSequenceDecorrelated(t *testing.T) {
+	base := 10 * time.Millisecond
+	seq := Iter(Decorrelated(), InitialDelay(base), MaxDelay(time.Hour), WithRand(rand.New(rand.NewPCG(1, 2))))
+
+	// Each delay is drawn from [base, prev*3] of the delay before it in the same iteration
+	prev := base
+	for delay := range Iter(Sequence(seq), MaxRetries(20)) {
+		if delay < base || delay > 3*prev {
+			t.Errorf("Expected delay in [%v, %v], got %v", base, 3*prev, delay)
+		}
+		prev = delay
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"time"
 
 	"github.com/scnewma/backoff"
@@ -75,6 +76,7 @@ func Example_customUsage() {
 		backoff.Multiplier(1.5),
 		backoff.JitterFactor(0.15), // 15% jitter
 		backoff.MaxRetries(4),
+		backoff.WithRand(rand.New(rand.NewPCG(1, 2))), // Seeded for reproducible output
 	) {
 		fmt.Printf("Attempt %d: ~%v\n", count+1, delay.Round(time.Millisecond))
 		count++
@@ -82,6 +84,12 @@ func Example_customUsage() {
 			break
 		}
 	}
+	// Output:
+	// Custom backoff with 15% jitter:
+	// Attempt 1: ~26ms
+	// Attempt 2: ~37ms
+	// Attempt 3: ~56ms
+	// Attempt 4: ~83ms
 }
 
 func Example_networkRetry() {
@@ -109,7 +117,7 @@ func ExampleRetryWithContext() {
 		return "success", nil
 	}, backoff.InitialDelay(5*time.Millisecond), backoff.MaxRetries(10))
 
-	if err == context.DeadlineExceeded {
+	if errors.Is(err, context.DeadlineExceeded) {
 		fmt.Printf("Operation timed out after %d attempts\n", attempts)
 	} else {
 		fmt.Printf("Result: %s, Attempts: %d\n", result, attempts)
@@ -158,6 +166,7 @@ func ExampleIter_exponential() {
 	for delay := range backoff.Iter(
 		backoff.Exponential(),
 		backoff.MaxRetries(4),
+		backoff.WithRand(rand.New(rand.NewPCG(1, 2))), // Seeded for reproducible output
 	) {
 		fmt.Printf("Attempt %d: ~%v\n", count+1, delay.Round(time.Millisecond))
 		count++
@@ -165,6 +174,12 @@ func ExampleIter_exponential() {
 			break
 		}
 	}
+	// Output:
+	// Exponential backoff delays (with jitter):
+	// Attempt 1: ~104ms
+	// Attempt 2: ~198ms
+	// Attempt 3: ~401ms
+	// Attempt 4: ~789ms
 }
 
 func ExampleRetry_constantBackoff() {
@@ -182,3 +197,89 @@ func ExampleRetry_constantBackoff() {
 	// Output:
 	// Result: success, Error: <nil>, Attempts: 3
 }
+
+func ExampleRetryFunc() {
+	result, err := backoff.RetryFunc(context.Background(), func(ctx context.Context, a backoff.Attempt) (string, error) {
+		fmt.Printf("Attempt %d (waited %v, previous error: %v)\n", a.Number, a.Delay, a.PrevErr)
+		if a.Number < 3 {
+			return "", errors.New("temporary failure")
+		}
+		return "success", nil
+	}, backoff.InitialDelay(10*time.Millisecond), backoff.JitterFactor(0), backoff.MaxRetries(5))
+
+	fmt.Printf("Result: %s, Error: %v\n", result, err)
+	// Output:
+	// Attempt 1 (waited 0s, previous error: <nil>)
+	// Attempt 2 (waited 10ms, previous error: temporary failure)
+	// Attempt 3 (waited 20ms, previous error: temporary failure)
+	// Result: success, Error: <nil>
+}
+
+func ExampleBackoff() {
+	b := backoff.New(
+		backoff.InitialDelay(100*time.Millisecond),
+		backoff.JitterFactor(0),
+		backoff.MaxRetries(3),
+	)
+
+	for {
+		delay, ok := b.Next()
+		if !ok {
+			fmt.Printf("Gave up after %d attempts\n", b.Attempt())
+			break
+		}
+		fmt.Printf("Reconnecting in %v\n", delay)
+	}
+
+	// After a successful connection, start over
+	b.Reset()
+	delay, _ := b.Next()
+	fmt.Printf("Reconnecting in %v\n", delay)
+	// Output:
+	// Reconnecting in 100ms
+	// Reconnecting in 200ms
+	// Reconnecting in 400ms
+	// Gave up after 3 attempts
+	// Reconnecting in 100ms
+}
+
+func ExampleCircuitBreaker() {
+	// Shared by every call to the same dependency
+	cb := backoff.NewCircuitBreaker(backoff.ConsecutiveFailures(3))
+
+	calls := 0
+	_, err := backoff.Retry(func() (string, error) {
+		calls++
+		return "", errors.New("service unavailable")
+	}, backoff.WithBreaker(cb), backoff.InitialDelay(time.Millisecond), backoff.MaxRetries(10))
+
+	fmt.Println("Calls:", calls)
+	fmt.Println("Circuit open:", errors.Is(err, backoff.ErrCircuitOpen))
+	var retryErr *backoff.RetryError
+	if errors.As(err, &retryErr) {
+		fmt.Println("Last error:", retryErr.Last())
+	}
+	fmt.Println("State:", cb.State())
+	// Output:
+	// Calls: 3
+	// Circuit open: true
+	// Last error: service unavailable
+	// State: open
+}
+
+func ExampleHedge() {
+	result, err := backoff.Hedge(context.Background(), func(ctx context.Context, a backoff.Attempt) (string, error) {
+		if a.Number == 1 {
+			// The first replica is stuck; it is cancelled once another attempt succeeds
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return fmt.Sprintf("response from attempt %d", a.Number), nil
+	}, backoff.Schedule(10*time.Millisecond))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result)
+	// Output: response from attempt 2
+}
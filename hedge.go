@@ -0,0 +1,175 @@
+package backoff
+
+import (
+	"context"
+	"time"
+)
+
+// MaxInFlight caps the number of attempts made by Hedge that run at the same time.
+// While the cap is reached, no new attempt starts until one of them fails.
+// If n is < 1, it defaults to 1. The default is 3.
+//
+// Example:
+//
+//	// Never more than 2 concurrent requests to the replica set
+//	user, err := backoff.Hedge(ctx, fetchUser, backoff.MaxInFlight(2))
+func MaxInFlight(n int) Option {
+	return func(c *config) {
+		c.maxInFlight = max(1, n)
+	}
+}
+
+// hedgeResult is the outcome of an attempt started by Hedge.
+type hedgeResult[T any] struct {
+	attempt  Attempt
+	start    time.Time
+	result   T
+	timedOut bool
+	err      error
+}
+
+// Hedge calls fn and, if it has not succeeded after the first delay of the schedule, calls
+// it again concurrently, and so on after each following delay, returning the first
+// successful result. The remaining attempts are cancelled through their context, and Hedge
+// returns without waiting for them. This trades extra load for lower tail latency on
+// idempotent reads, for example from replicated services.
+//
+// Attempts are started at the delays of Iter with the same options, at most MaxInFlight at
+// a time, and at most MaxRetries beyond the first. An attempt that fails does not start
+// another one early: new attempts still follow the schedule, so failures are retried with
+// backoff. Errors are classified as by RetryFunc: a cancel error, or an error rejected by
+// RetryIf, is returned at once. If every attempt fails, or ctx ends first, a *RetryError is
+// returned, listing the attempts in the order they failed.
+//
+// WithBreaker and WithBudget apply as for RetryFunc, each attempt after the first counting
+// as a retry; once they allow no more attempts, the attempts in flight still run, and if all
+// of them fail a *RetryError is returned whose StopErr is ErrCircuitOpen or
+// ErrBudgetExhausted.
+//
+// Example:
+//
+//	// Send a second request if the first takes longer than 50ms, and a third after 100ms more
+//	user, err := backoff.Hedge(ctx, func(ctx context.Context, _ backoff.Attempt) (*User, error) {
+//	    return client.GetUser(ctx, id)
+//	}, backoff.Schedule(50*time.Millisecond, 100*time.Millisecond))
+func Hedge[T any](ctx context.Context, fn func(context.Context, Attempt) (T, error), options ...Option) (T, error) {
+	cfg := newConfig(options...)
+	start := cfg.clock.Now()
+	schedule := newBackoff(cfg, start)
+	defer schedule.release()
+
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	results := make(chan hedgeResult[T])
+
+	var zero T
+	var lastErr error
+	var failed []AttemptError
+	var inFlight, launched int
+	var stopErr error // ErrCircuitOpen or ErrBudgetExhausted once no more attempts are allowed
+
+	// launch starts an attempt in a new goroutine and reports whether it was allowed
+	launch := func(delay time.Duration) bool {
+		if launched > 0 && cfg.budget != nil && !cfg.budget.withdraw() {
+			stopErr = ErrBudgetExhausted
+			return false
+		}
+		var generation uint64
+		if cfg.breaker != nil {
+			var ok bool
+			if generation, ok = cfg.breaker.allow(); !ok {
+				stopErr = ErrCircuitOpen
+				return false
+			}
+		}
+
+		launched++
+		inFlight++
+		attempt := Attempt{
+			Number:  launched,
+			Delay:   delay,
+			Elapsed: cfg.clock.Now().Sub(start),
+			PrevErr: lastErr,
+		}
+		attemptStart := cfg.clock.Now()
+		go func() {
+			result, timedOut, err := callAttempt(hedgeCtx, cfg, fn, attempt)
+			if cfg.breaker != nil {
+				cfg.breakerDone(hedgeCtx, generation, err, timedOut)
+			}
+			select {
+			case results <- hedgeResult[T]{attempt: attempt, start: attemptStart, result: result, timedOut: timedOut, err: err}:
+			case <-done:
+			}
+		}()
+		return true
+	}
+
+	if cfg.budget != nil {
+		cfg.budget.request()
+	}
+	if !launch(0) {
+		return zero, stopErr
+	}
+
+	next, more := schedule.Next()
+	var timer Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		// The next hedge waits for its delay once there is room for it
+		var fire <-chan time.Time
+		if more && stopErr == nil && inFlight < cfg.maxInFlight {
+			if timer == nil {
+				timer = cfg.clock.NewTimer(next)
+			}
+			fire = timer.C()
+		}
+
+		select {
+		case <-ctx.Done():
+			return zero, &RetryError{Attempts: failed, ContextErr: ctx.Err()}
+
+		case <-fire:
+			timer = nil
+			if launch(next) {
+				next, more = schedule.Next()
+			}
+
+		case r := <-results:
+			inFlight--
+			stop, err := cfg.outcome(r.result, r.err, r.timedOut)
+			if err == nil {
+				return r.result, nil
+			}
+
+			lastErr = err
+			failed = append(failed, AttemptError{
+				Number: r.attempt.Number,
+				Start:  r.start,
+				Delay:  r.attempt.Delay,
+				Err:    recorded(err),
+			})
+			if stop {
+				return r.result, err
+			}
+			if inFlight > 0 || (more && stopErr == nil) {
+				continue
+			}
+			if stopErr != nil {
+				return zero, &RetryError{Attempts: failed, StopErr: stopErr}
+			}
+			return r.result, exhausted(failed, err)
+		}
+
+		if inFlight == 0 && stopErr != nil {
+			return zero, &RetryError{Attempts: failed, StopErr: stopErr}
+		}
+	}
+}
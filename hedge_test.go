@@ -0,0 +1,200 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedgeFirstAttemptSucceeds(t *testing.T) {
+	var calls atomic.Int32
+
+	result, err := Hedge(context.Background(), func(context.Context, Attempt) (string, error) {
+		calls.Add(1)
+		return "success", nil
+	}, Schedule(time.Hour))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "success" {
+		t.Errorf("Expected result 'success', got %v", result)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 call, got %d", calls.Load())
+	}
+}
+
+func TestHedgeCancelsSlowAttempts(t *testing.T) {
+	clock := &instantClock{}
+	cancelled := make(chan int, 1)
+
+	result, err := Hedge(context.Background(), func(ctx context.Context, a Attempt) (int, error) {
+		if a.Number == 1 {
+			<-ctx.Done()
+			cancelled <- a.Number
+			return 0, ctx.Err()
+		}
+		return a.Number, nil
+	}, WithClock(clock), Schedule(time.Second))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != 2 {
+		t.Errorf("Expected result from attempt 2, got %d", result)
+	}
+	select {
+	case n := <-cancelled:
+		if n != 1 {
+			t.Errorf("Expected attempt 1 to be cancelled, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected attempt 1 to be cancelled after attempt 2 succeeded")
+	}
+	if len(clock.sleeps) != 1 || clock.sleeps[0] != time.Second {
+		t.Errorf("Expected a single hedge delay of %v, got %v", time.Second, clock.sleeps)
+	}
+}
+
+func TestHedgeMaxInFlight(t *testing.T) {
+	clock := &instantClock{}
+	var running atomic.Int32
+	started := make(chan int, 10)
+	failFirst := make(chan struct{})
+
+	go func() {
+		// Let attempt 1 fail once attempts 1 and 2 are running and no third one started
+		<-started
+		<-started
+		time.Sleep(20 * time.Millisecond)
+		if len(started) != 0 {
+			t.Errorf("Expected no more than 2 attempts to start while 2 are in flight")
+		}
+		close(failFirst)
+	}()
+
+	result, err := Hedge(context.Background(), func(ctx context.Context, a Attempt) (int, error) {
+		if n := running.Add(1); n > 2 {
+			t.Errorf("Expected at most 2 attempts in flight, got %d", n)
+		}
+		defer running.Add(-1)
+		started <- a.Number
+
+		switch a.Number {
+		case 1:
+			<-failFirst
+			return 0, errors.New("replica unavailable")
+		case 2:
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return a.Number, nil
+	}, WithClock(clock), Constant(), MaxInFlight(2), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != 3 {
+		t.Errorf("Expected result from attempt 3, got %d", result)
+	}
+}
+
+func TestHedgeAllAttemptsFail(t *testing.T) {
+	clock := &instantClock{}
+	var calls atomic.Int32
+
+	_, err := Hedge(context.Background(), func(context.Context, Attempt) (int, error) {
+		calls.Add(1)
+		return 0, errors.New("unavailable")
+	}, WithClock(clock), MaxRetries(2))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %v", err)
+	}
+	if len(retryErr.Attempts) != 3 {
+		t.Errorf("Expected 3 failed attempts, got %d", len(retryErr.Attempts))
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls.Load())
+	}
+}
+
+func TestHedgeCancelError(t *testing.T) {
+	permanent := errors.New("not found")
+
+	_, err := Hedge(context.Background(), func(context.Context, Attempt) (int, error) {
+		return 0, Cancel(permanent)
+	}, Schedule(time.Hour))
+
+	if !IsCancel(err) || !errors.Is(err, permanent) {
+		t.Errorf("Expected cancel error wrapping %v, got %v", permanent, err)
+	}
+}
+
+func TestHedgeContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+
+	_, err := Hedge(ctx, func(ctx context.Context, a Attempt) (int, error) {
+		once.Do(cancel)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, Schedule(time.Hour))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !errors.Is(retryErr.ContextErr, context.Canceled) {
+		t.Errorf("Expected RetryError with context.Canceled, got %v", err)
+	}
+}
+
+func TestHedgeWithOpenBreaker(t *testing.T) {
+	clock := &instantClock{}
+	cb := newTestBreaker(clock, ConsecutiveFailures(1))
+	call(cb, false)
+
+	called := false
+	_, err := Hedge(context.Background(), func(context.Context, Attempt) (int, error) {
+		called = true
+		return 1, nil
+	}, WithBreaker(cb))
+
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if called {
+		t.Errorf("Expected fn not to be called while the breaker is open")
+	}
+}
+
+func TestHedgeWithBudget(t *testing.T) {
+	clock := &instantClock{}
+	b := NewRetryBudget(BudgetClock(clock), RetryRatio(0), MinRetriesPerSecond(0.1), BudgetWindow(10*time.Second))
+	var calls atomic.Int32
+
+	result, err := Hedge(context.Background(), func(context.Context, Attempt) (int, error) {
+		calls.Add(1)
+		return 1, errTemporary
+	}, WithBudget(b), WithClock(clock), MaxRetries(5))
+
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted, got %v", err)
+	}
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 {
+		t.Errorf("Expected RetryError with 2 attempts, got %v", err)
+	}
+	if !errors.Is(err, errTemporary) {
+		t.Errorf("Expected the attempt errors to be wrapped, got %v", err)
+	}
+	if result != 0 {
+		t.Errorf("Expected zero result, got %d", result)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls.Load())
+	}
+}
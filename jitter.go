@@ -0,0 +1,62 @@
+package backoff
+
+import "time"
+
+// JitterMode randomizes a base delay to avoid thundering herd problems.
+// It is called with the capped base delay d and a random value r uniformly distributed
+// in [0, 1), and returns the delay to yield. Iter clamps the result to [0, MaxDelay].
+//
+// FullJitter, EqualJitter and Symmetric cover the common algorithms; any function with
+// this signature can be passed to Jitter.
+type JitterMode func(d time.Duration, r float64) time.Duration
+
+// FullJitter draws the delay uniformly from [0, d).
+//
+// The mean delay is d/2. Full jitter spreads competing clients out the most, at the cost
+// of occasionally retrying almost immediately.
+func FullJitter(d time.Duration, r float64) time.Duration {
+	return time.Duration(r * float64(d))
+}
+
+// EqualJitter keeps half of the delay and draws the other half uniformly,
+// yielding a delay in [d/2, d).
+//
+// The mean delay is 3d/4. Equal jitter guarantees a minimum wait of d/2 while still
+// spreading clients across half of the interval.
+func EqualJitter(d time.Duration, r float64) time.Duration {
+	half := d / 2
+	return half + time.Duration(r*float64(d-half))
+}
+
+// Symmetric returns a JitterMode that adds a random value between -factor*d and +factor*d,
+// yielding a delay in [d-factor*d, d+factor*d).
+// The factor represents a percentage (0.1 = 10% jitter). If factor < 0, it defaults to 0.
+//
+// The mean delay is d, so symmetric jitter preserves the shape of the underlying schedule.
+func Symmetric(factor float64) JitterMode {
+	if factor < 0 {
+		factor = 0
+	}
+	return func(d time.Duration, r float64) time.Duration {
+		jitter := (r - 0.5) * 2 * float64(d) * factor
+		return time.Duration(float64(d) + jitter)
+	}
+}
+
+// Jitter sets the algorithm used to randomize each delay. A nil mode disables jitter.
+// It replaces any jitter configured previously, including by JitterFactor or a strategy preset.
+//
+// Example:
+//
+//	for delay := range backoff.Iter(backoff.Jitter(backoff.FullJitter)) {
+//	    // Each delay will be uniformly distributed between 0 and the exponential delay
+//	}
+//
+//	for delay := range backoff.Iter(backoff.Jitter(backoff.Symmetric(0.2))) {
+//	    // Each delay will have ±20% random variation
+//	}
+func Jitter(mode JitterMode) Option {
+	return func(c *config) {
+		c.jitter = mode
+	}
+}
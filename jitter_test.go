@@ -0,0 +1,133 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+// sampleJitter collects n first delays from Iter with the given jitter mode applied
+// to a constant 100ms base delay.
+func sampleJitter(mode JitterMode, n int) []time.Duration {
+	var delays []time.Duration
+	for range n {
+		for delay := range Iter(Constant(), InitialDelay(100*time.Millisecond), Jitter(mode), MaxRetries(1)) {
+			delays = append(delays, delay)
+		}
+	}
+	return delays
+}
+
+func mean(delays []time.Duration) time.Duration {
+	var sum time.Duration
+	for _, d := range delays {
+		sum += d
+	}
+	return sum / time.Duration(len(delays))
+}
+
+func TestFullJitter(t *testing.T) {
+	delays := sampleJitter(FullJitter, 2000)
+
+	for i, delay := range delays {
+		if delay < 0 || delay >= 100*time.Millisecond {
+			t.Fatalf("Delay %d: %v is outside expected range [0, 100ms)", i, delay)
+		}
+	}
+
+	// Mean of uniform [0, 100ms) is 50ms
+	if m := mean(delays); m < 45*time.Millisecond || m > 55*time.Millisecond {
+		t.Errorf("Expected mean around 50ms, got %v", m)
+	}
+}
+
+func TestEqualJitter(t *testing.T) {
+	delays := sampleJitter(EqualJitter, 2000)
+
+	for i, delay := range delays {
+		if delay < 50*time.Millisecond || delay >= 100*time.Millisecond {
+			t.Fatalf("Delay %d: %v is outside expected range [50ms, 100ms)", i, delay)
+		}
+	}
+
+	// Mean of 50ms + uniform [0, 50ms) is 75ms
+	if m := mean(delays); m < 72*time.Millisecond || m > 78*time.Millisecond {
+		t.Errorf("Expected mean around 75ms, got %v", m)
+	}
+}
+
+func TestSymmetricJitter(t *testing.T) {
+	delays := sampleJitter(Symmetric(0.2), 2000)
+
+	for i, delay := range delays {
+		if delay < 80*time.Millisecond || delay > 120*time.Millisecond {
+			t.Fatalf("Delay %d: %v is outside expected range [80ms, 120ms]", i, delay)
+		}
+	}
+
+	// Symmetric jitter is centered on the base delay
+	if m := mean(delays); m < 98*time.Millisecond || m > 102*time.Millisecond {
+		t.Errorf("Expected mean around 100ms, got %v", m)
+	}
+}
+
+func TestJitterModeBounds(t *testing.T) {
+	d := 100 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		mode     JitterMode
+		low      time.Duration
+		high     time.Duration
+		highExcl bool
+	}{
+		{"full", FullJitter, 0, d, true},
+		{"equal", EqualJitter, d / 2, d, true},
+		{"symmetric", Symmetric(0.5), d / 2, 3 * d / 2, true},
+		{"symmetric negative factor", Symmetric(-1), d, d, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mode(d, 0); got != tt.low {
+			t.Errorf("%s: expected %v at r=0, got %v", tt.name, tt.low, got)
+		}
+		got := tt.mode(d, 0.9999999)
+		if got > tt.high || (tt.highExcl && got == tt.high) {
+			t.Errorf("%s: expected below %v at r≈1, got %v", tt.name, tt.high, got)
+		}
+	}
+}
+
+func TestJitterCappedAtMaxDelay(t *testing.T) {
+	for range 200 {
+		for delay := range Iter(
+			Constant(),
+			InitialDelay(100*time.Millisecond),
+			MaxDelay(100*time.Millisecond),
+			Jitter(Symmetric(0.5)),
+			MaxRetries(1),
+		) {
+			if delay > 100*time.Millisecond {
+				t.Fatalf("Expected delay capped at 100ms, got %v", delay)
+			}
+		}
+	}
+}
+
+func TestJitterOverridesJitterFactor(t *testing.T) {
+	var delays []time.Duration
+	for delay := range Iter(
+		InitialDelay(100*time.Millisecond),
+		JitterFactor(0.5),
+		Jitter(nil), // disables the jitter configured above
+		MaxRetries(2),
+	) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
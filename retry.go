@@ -0,0 +1,456 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Attempt describes a single call made by RetryFunc.
+type Attempt struct {
+	// Number is the 1-based attempt number. The initial call is attempt 1.
+	Number int
+
+	// Delay is the backoff delay waited before this attempt. It is zero for the first attempt.
+	Delay time.Duration
+
+	// Elapsed is the time since the first attempt started.
+	Elapsed time.Duration
+
+	// PrevErr is the error returned by the previous attempt. It is nil for the first attempt.
+	PrevErr error
+}
+
+// AttemptError records a single failed attempt made by RetryFunc.
+type AttemptError struct {
+	// Number is the 1-based attempt number.
+	Number int
+
+	// Start is the time the attempt started.
+	Start time.Time
+
+	// Delay is the backoff delay waited before the attempt. It is zero for the first attempt.
+	Delay time.Duration
+
+	// Err is the error returned by the attempt.
+	Err error
+}
+
+// RetryError is returned by RetryFunc, RetryWithContext and Retry when retries are exhausted,
+// the context ends, or the circuit breaker or retry budget stops retries before an attempt
+// succeeds. It records every failed attempt.
+//
+// RetryError unwraps to the error of every attempt and to the context or stop error, so
+// errors.Is and errors.As match against any of them:
+//
+//	_, err := backoff.RetryWithContext(ctx, fn)
+//	if errors.Is(err, context.DeadlineExceeded) {
+//	    // the context expired while retrying
+//	}
+//	var retryErr *backoff.RetryError
+//	if errors.As(err, &retryErr) {
+//	    log.Printf("gave up after %d attempts: %v", len(retryErr.Attempts), retryErr.Last())
+//	}
+type RetryError struct {
+	// Attempts holds every failed attempt, in order.
+	Attempts []AttemptError
+
+	// ContextErr is the context error if retries stopped because the context ended, or nil
+	// if retries were exhausted.
+	ContextErr error
+
+	// StopErr is ErrCircuitOpen or ErrBudgetExhausted if retries stopped because the
+	// circuit breaker attached with WithBreaker or the retry budget attached with WithBudget
+	// allowed no more attempts, ErrConditionNotMet if retries were exhausted while the
+	// result of the last attempt was not ready, or nil.
+	StopErr error
+}
+
+// maxErrorLines is the number of attempts listed in full by RetryError.Error.
+const maxErrorLines = 10
+
+// Error renders a summary line followed by one line per attempt. Long histories are
+// shortened to their first and last attempts.
+func (e *RetryError) Error() string {
+	var b strings.Builder
+	switch {
+	case e.ContextErr != nil:
+		fmt.Fprintf(&b, "backoff: %v after %d attempts", e.ContextErr, len(e.Attempts))
+	case e.StopErr != nil:
+		fmt.Fprintf(&b, "%v after %d attempts", e.StopErr, len(e.Attempts))
+	default:
+		fmt.Fprintf(&b, "backoff: %d attempts failed", len(e.Attempts))
+	}
+	if last := e.Last(); last != nil {
+		fmt.Fprintf(&b, ", last error: %v", last)
+	}
+
+	for i, a := range e.Attempts {
+		if len(e.Attempts) > maxErrorLines && i == maxErrorLines/2 {
+			fmt.Fprintf(&b, "\n  ... %d more attempts", len(e.Attempts)-maxErrorLines)
+		}
+		if len(e.Attempts) > maxErrorLines && i >= maxErrorLines/2 && i < len(e.Attempts)-maxErrorLines/2 {
+			continue
+		}
+		if a.Delay > 0 {
+			fmt.Fprintf(&b, "\n  attempt %d (after %v): %v", a.Number, a.Delay, a.Err)
+		} else {
+			fmt.Fprintf(&b, "\n  attempt %d: %v", a.Number, a.Err)
+		}
+	}
+	return b.String()
+}
+
+// Unwrap returns the error of every attempt followed by the context and stop errors, if any.
+// This allows RetryError to work with errors.Is and errors.As.
+func (e *RetryError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts)+2)
+	for _, a := range e.Attempts {
+		errs = append(errs, a.Err)
+	}
+	if e.ContextErr != nil {
+		errs = append(errs, e.ContextErr)
+	}
+	if e.StopErr != nil {
+		errs = append(errs, e.StopErr)
+	}
+	return errs
+}
+
+// Last returns the error of the last attempt, or nil if no attempt was recorded.
+func (e *RetryError) Last() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// AttemptTimeout bounds the duration of each individual attempt made by RetryFunc.
+// Every call to fn receives a child context that is cancelled after d. An attempt that
+// fails because its own deadline passed is retried like any other error, whereas the
+// deadline of the parent context still stops all retries.
+// If d is <= 0, attempts are not bounded (the default).
+//
+// The per-attempt context is only visible to RetryFunc callbacks; Retry and RetryWithContext
+// callbacks take no context and cannot observe it.
+//
+// Example:
+//
+//	resp, err := backoff.RetryFunc(ctx, func(ctx context.Context, _ backoff.Attempt) (*http.Response, error) {
+//	    req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	    return http.DefaultClient.Do(req) // each request is abandoned after 2 seconds
+//	}, backoff.AttemptTimeout(2*time.Second), backoff.MaxRetries(5))
+func AttemptTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.attemptTimeout = max(0, d)
+	}
+}
+
+// RetryIf registers a classifier that decides whether an error returned by fn should be
+// retried. Retries stop as soon as the classifier returns false for an error, and that
+// error is returned. When several classifiers are registered, an error is retried only
+// if all of them accept it. Cancel errors always stop retries, and attempts that ran out
+// of time under AttemptTimeout are always retried.
+//
+// Example:
+//
+//	user, err := backoff.Retry(fetchUser, backoff.RetryIf(func(err error) bool {
+//	    var netErr net.Error
+//	    return errors.As(err, &netErr) && netErr.Timeout()
+//	}))
+func RetryIf(retryable func(error) bool) Option {
+	return func(c *config) {
+		c.retryIf = append(c.retryIf, retryable)
+	}
+}
+
+// RetryOnErrors returns an Option that retries only errors matching one of targets
+// according to errors.Is. Any other error stops retries immediately.
+//
+// Example:
+//
+//	rows, err := backoff.Retry(query, backoff.RetryOnErrors(sql.ErrConnDone, driver.ErrBadConn))
+func RetryOnErrors(targets ...error) Option {
+	return RetryIf(func(err error) bool {
+		return matchesAny(err, targets)
+	})
+}
+
+// StopOnErrors returns an Option that stops retries on errors matching one of targets
+// according to errors.Is, as if they had been wrapped with Cancel. Any other error is retried.
+//
+// Example:
+//
+//	data, err := backoff.Retry(readFile, backoff.StopOnErrors(fs.ErrNotExist, fs.ErrPermission))
+func StopOnErrors(targets ...error) Option {
+	return RetryIf(func(err error) bool {
+		return !matchesAny(err, targets)
+	})
+}
+
+func matchesAny(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrConditionNotMet is passed as Attempt.PrevErr after an attempt whose result a RetryWhile
+// predicate reported as not ready. When retries are exhausted and the result of the last
+// attempt was not ready, the returned *RetryError matches it with errors.Is.
+var ErrConditionNotMet = errors.New("backoff: condition not met")
+
+// errNotReady is recorded in a RetryError for an attempt whose result was not ready, so that
+// earlier attempts do not make the RetryError match ErrConditionNotMet.
+var errNotReady = errors.New("backoff: result not ready")
+
+// recorded returns the error to record in a RetryError for an attempt that failed with err.
+func recorded(err error) error {
+	if err == ErrConditionNotMet {
+		return errNotReady
+	}
+	return err
+}
+
+// exhausted returns the error of a retry loop that ran out of attempts, the last of which
+// failed with lastErr.
+func exhausted(failed []AttemptError, lastErr error) error {
+	if lastErr == ErrConditionNotMet {
+		return &RetryError{Attempts: failed, StopErr: ErrConditionNotMet}
+	}
+	return &RetryError{Attempts: failed}
+}
+
+// RetryWhile returns an Option that keeps retrying while pending reports true for the result
+// and error of an attempt. This allows polling an operation that succeeds with a "not ready
+// yet" value, such as a job in a PENDING state. After a successful attempt that pending
+// accepts, ErrConditionNotMet is passed to the next attempt as Attempt.PrevErr. Attempts that pending accepts are retried even if RetryIf would reject
+// their error, but cancel errors always stop retries. When pending reports false, a
+// successful result is returned immediately and an error is classified as usual.
+// If retries are exhausted, the last result is returned with an error that matches
+// ErrConditionNotMet only if the last attempt succeeded.
+//
+// Options are not typed by the function they are passed with, so T must match the result
+// type of the retried function: RetryWhile[int64] used with a function returning int stops
+// on the first attempt with a cancel error describing the mismatch. RetryFuncWhile takes
+// the predicate as an argument instead, so that a mismatch does not compile.
+//
+// Example:
+//
+//	job, err := backoff.Retry(func() (*Job, error) {
+//	    return client.GetJob(id)
+//	}, backoff.RetryWhile(func(job *Job, err error) bool {
+//	    return err == nil && job.Status == "PENDING"
+//	}), backoff.MaxRetries(10))
+//	if errors.Is(err, backoff.ErrConditionNotMet) {
+//	    // job is still pending
+//	}
+func RetryWhile[T any](pending func(T, error) bool) Option {
+	return func(c *config) {
+		c.retryWhile = func(v any, err error) (bool, error) {
+			result, ok := v.(T)
+			if !ok && v != nil {
+				return false, fmt.Errorf("backoff: RetryWhile[%v] used with a function returning %T", reflect.TypeFor[T](), v)
+			}
+			return pending(result, err), nil
+		}
+	}
+}
+
+// RetryFuncWhile behaves like RetryFunc with RetryWhile(pending) among options, but pending
+// is checked against the result type of fn when compiling. A RetryWhile option in options
+// is replaced by pending.
+//
+// Example:
+//
+//	job, err := backoff.RetryFuncWhile(ctx, func(ctx context.Context, _ backoff.Attempt) (*Job, error) {
+//	    return client.GetJob(ctx, id)
+//	}, func(job *Job, err error) bool {
+//	    return err == nil && job.Status == "PENDING"
+//	}, backoff.MaxRetries(10))
+func RetryFuncWhile[T any](ctx context.Context, fn func(context.Context, Attempt) (T, error), pending func(T, error) bool, options ...Option) (T, error) {
+	return RetryFunc(ctx, fn, append(slices.Clip(options), RetryWhile(pending))...)
+}
+
+// RetryFunc executes a context-aware function with automatic retry logic and context cancellation.
+// It behaves like RetryWithContext, but fn receives the context it runs under and an Attempt
+// describing the current call, so it can propagate cancellation into I/O, log attempt numbers,
+// or adjust its behavior on later attempts.
+//
+// Example:
+//
+//	body, err := backoff.RetryFunc(ctx, func(ctx context.Context, a backoff.Attempt) ([]byte, error) {
+//	    if a.PrevErr != nil {
+//	        log.Printf("attempt %d after %v: previous error: %v", a.Number, a.Elapsed, a.PrevErr)
+//	    }
+//	    req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com/data", nil)
+//	    if err != nil {
+//	        return nil, backoff.Cancel(err)
+//	    }
+//	    resp, err := http.DefaultClient.Do(req)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    defer resp.Body.Close()
+//	    return io.ReadAll(resp.Body)
+//	}, backoff.MaxRetries(5))
+func RetryFunc[T any](ctx context.Context, fn func(context.Context, Attempt) (T, error), options ...Option) (T, error) {
+	cfg := newConfig(options...)
+	start := cfg.clock.Now()
+
+	var zero, result T
+	var lastErr error
+	var failed []AttemptError
+
+	// try runs a single attempt and reports whether retries should stop
+	try := func(attempt Attempt) bool {
+		attemptStart := cfg.clock.Now()
+		var generation uint64
+		if cfg.breaker != nil {
+			var ok bool
+			if generation, ok = cfg.breaker.allow(); !ok {
+				result = zero
+				lastErr = ErrCircuitOpen
+				if len(failed) > 0 {
+					lastErr = &RetryError{Attempts: failed, StopErr: ErrCircuitOpen}
+				}
+				return true
+			}
+		}
+
+		var timedOut bool
+		result, timedOut, lastErr = callAttempt(ctx, cfg, fn, attempt)
+		if cfg.breaker != nil {
+			cfg.breakerDone(ctx, generation, lastErr, timedOut)
+		}
+		var stop bool
+		stop, lastErr = cfg.outcome(result, lastErr, timedOut)
+		if lastErr == nil {
+			return true
+		}
+
+		failed = append(failed, AttemptError{
+			Number: attempt.Number,
+			Start:  attemptStart,
+			Delay:  attempt.Delay,
+			Err:    recorded(lastErr),
+		})
+		return stop
+	}
+
+	if cfg.budget != nil {
+		cfg.budget.request()
+	}
+
+	attempt := Attempt{Number: 1}
+	if try(attempt) {
+		return result, lastErr
+	}
+
+	for delay := range cfg.delays(start) {
+		// The parent context ending is terminal, even if the next delay is zero
+		if ctx.Err() != nil {
+			return result, &RetryError{Attempts: failed, ContextErr: ctx.Err()}
+		}
+
+		if cfg.budget != nil && !cfg.budget.withdraw() {
+			return zero, &RetryError{Attempts: failed, StopErr: ErrBudgetExhausted}
+		}
+
+		if hint, ok := retryAfter(lastErr); ok {
+			if delay, ok = cfg.hintedDelay(ctx, start, hint); !ok {
+				// Waiting out the hint would only wake up to an expired context
+				return result, &RetryError{Attempts: failed, ContextErr: context.DeadlineExceeded}
+			}
+		}
+
+		if !sleep(ctx, cfg.clock, delay) {
+			return result, &RetryError{Attempts: failed, ContextErr: ctx.Err()}
+		}
+
+		attempt = Attempt{
+			Number:  attempt.Number + 1,
+			Delay:   delay,
+			Elapsed: cfg.clock.Now().Sub(start),
+			PrevErr: lastErr,
+		}
+		if try(attempt) {
+			return result, lastErr
+		}
+	}
+
+	return result, exhausted(failed, lastErr)
+}
+
+// hintedDelay limits a delay hinted by a RetryAfter error to MaxDelay and to the time left
+// of MaxElapsedTime since start. It reports false if the delay does not end before the
+// context deadline, which is measured on the real clock like the context itself.
+func (c *config) hintedDelay(ctx context.Context, start time.Time, hint time.Duration) (time.Duration, bool) {
+	delay := min(hint, c.maxDelay)
+	if c.maxElapsed > 0 {
+		delay = min(delay, c.maxElapsed-c.clock.Now().Sub(start))
+	}
+	delay = max(0, delay)
+	if deadline, ok := ctx.Deadline(); ok && delay >= time.Until(deadline) {
+		return 0, false
+	}
+	return delay, true
+}
+
+// outcome classifies an attempt that returned result and err. It returns the error to
+// record for the attempt, nil if it succeeded, and reports whether retries should stop.
+func (c *config) outcome(result any, err error, timedOut bool) (bool, error) {
+	var pending bool
+	if c.retryWhile != nil {
+		var mismatch error
+		if pending, mismatch = c.retryWhile(result, err); mismatch != nil {
+			return true, Cancel(mismatch)
+		}
+	}
+	if err == nil {
+		if !pending {
+			return true, nil
+		}
+		return false, ErrConditionNotMet
+	}
+	if pending {
+		return IsCancel(err), err
+	}
+	return !timedOut && !c.retryable(err), err
+}
+
+// retryable reports whether err allows another attempt: it must not be a cancel error
+// and every classifier registered with RetryIf must accept it.
+func (c *config) retryable(err error) bool {
+	if IsCancel(err) {
+		return false
+	}
+	for _, retryIf := range c.retryIf {
+		if !retryIf(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// callAttempt invokes fn for a single attempt, bounding it with the configured attempt
+// timeout if any. It reports whether the attempt ran out of time while the parent context
+// is still live.
+func callAttempt[T any](ctx context.Context, c *config, fn func(context.Context, Attempt) (T, error), attempt Attempt) (T, bool, error) {
+	if c.attemptTimeout <= 0 {
+		result, err := fn(ctx, attempt)
+		return result, false, err
+	}
+
+	attemptCtx, cancel := withTimeout(ctx, c.clock, c.attemptTimeout)
+	defer cancel()
+	result, err := fn(attemptCtx, attempt)
+	timedOut := err != nil && !IsCancel(err) && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+	return result, timedOut, err
+}
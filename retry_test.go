@@ -0,0 +1,759 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryFuncAttemptInfo(t *testing.T) {
+	clock := &instantClock{}
+	var attempts []Attempt
+	errs := []error{errors.New("first failure"), errors.New("second failure")}
+
+	result, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (string, error) {
+		attempts = append(attempts, a)
+		if a.Number <= len(errs) {
+			return "", errs[a.Number-1]
+		}
+		return "success", nil
+	}, WithClock(clock), InitialDelay(1*time.Millisecond), Multiplier(2.0), JitterFactor(0), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "success" {
+		t.Errorf("Expected result 'success', got %v", result)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(attempts))
+	}
+
+	expectedDelays := []time.Duration{0, 1 * time.Millisecond, 2 * time.Millisecond}
+	expectedErrs := []error{nil, errs[0], errs[1]}
+	for i, a := range attempts {
+		if a.Number != i+1 {
+			t.Errorf("Attempt %d: expected number %d, got %d", i, i+1, a.Number)
+		}
+		if a.Delay != expectedDelays[i] {
+			t.Errorf("Attempt %d: expected delay %v, got %v", i, expectedDelays[i], a.Delay)
+		}
+		if a.PrevErr != expectedErrs[i] {
+			t.Errorf("Attempt %d: expected previous error %v, got %v", i, expectedErrs[i], a.PrevErr)
+		}
+	}
+
+	if attempts[0].Elapsed != 0 {
+		t.Errorf("Expected first attempt to have zero elapsed time, got %v", attempts[0].Elapsed)
+	}
+	if attempts[2].Elapsed != 3*time.Millisecond {
+		t.Errorf("Expected third attempt elapsed time of 3ms, got %v", attempts[2].Elapsed)
+	}
+}
+
+func TestRetryFuncPassesContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	_, err := RetryFunc(ctx, func(ctx context.Context, _ Attempt) (bool, error) {
+		if ctx.Value(key{}) != "value" {
+			return false, Cancel(errors.New("context not propagated"))
+		}
+		return true, nil
+	})
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestRetryFuncCancellation(t *testing.T) {
+	attempts := 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := RetryFunc(ctx, func(ctx context.Context, a Attempt) (int, error) {
+		attempts++
+		if a.Number == 2 {
+			cancel()
+		}
+		return 0, errors.New("always fails")
+	}, InitialDelay(1*time.Millisecond), MaxRetries(10))
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Canceled error, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryFuncAttemptTimeout(t *testing.T) {
+	attempts := 0
+
+	result, err := RetryFunc(context.Background(), func(ctx context.Context, a Attempt) (string, error) {
+		attempts++
+		if a.Number < 3 {
+			// Simulate a hanging call that only returns once its context is done
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "success", nil
+	}, AttemptTimeout(5*time.Millisecond), InitialDelay(1*time.Millisecond), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "success" {
+		t.Errorf("Expected result 'success', got %v", result)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryFuncAttemptTimeoutDerivesFromParent(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	_, err := RetryFunc(ctx, func(ctx context.Context, _ Attempt) (bool, error) {
+		if ctx.Value(key{}) != "value" {
+			return false, Cancel(errors.New("parent context values not propagated"))
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			return false, Cancel(errors.New("attempt context has no deadline"))
+		}
+		return true, nil
+	}, AttemptTimeout(time.Second))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestRetryFuncParentDeadlineIsTerminal(t *testing.T) {
+	attempts := 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := RetryFunc(ctx, func(ctx context.Context, _ Attempt) (int, error) {
+		attempts++
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, AttemptTimeout(time.Hour), InitialDelay(1*time.Millisecond), MaxRetries(10))
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryIf(t *testing.T) {
+	attempts := 0
+	permanent := errors.New("permanent failure")
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("temporary failure")
+		}
+		return 0, permanent
+	}, RetryIf(func(err error) bool {
+		return err != permanent
+	}), InitialDelay(1*time.Millisecond), MaxRetries(10))
+
+	if err != permanent {
+		t.Errorf("Expected %v, got %v", permanent, err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryIfAllClassifiersMustAccept(t *testing.T) {
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, errors.New("failure")
+	}, RetryIf(func(error) bool { return true }), RetryIf(func(error) bool { return false }),
+		InitialDelay(1*time.Millisecond), MaxRetries(10))
+
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryOnErrors(t *testing.T) {
+	errTransient := errors.New("transient")
+	errFatal := errors.New("fatal")
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, fmt.Errorf("call: %w", errTransient)
+		}
+		return 0, errFatal
+	}, RetryOnErrors(errTransient), InitialDelay(1*time.Millisecond), MaxRetries(10))
+
+	if err != errFatal {
+		t.Errorf("Expected %v, got %v", errFatal, err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestStopOnErrors(t *testing.T) {
+	errNotFound := errors.New("not found")
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		if attempts < 2 {
+			return 0, errors.New("temporary failure")
+		}
+		return 0, fmt.Errorf("lookup: %w", errNotFound)
+	}, StopOnErrors(errNotFound), InitialDelay(1*time.Millisecond), MaxRetries(10))
+
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Expected error wrapping %v, got %v", errNotFound, err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryIfDoesNotOverrideCancel(t *testing.T) {
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, Cancel(errors.New("permanent failure"))
+	}, RetryIf(func(error) bool { return true }), InitialDelay(1*time.Millisecond), MaxRetries(10))
+
+	if !IsCancel(err) {
+		t.Errorf("Expected cancel error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryIfKeepsAttemptTimeoutsRetryable(t *testing.T) {
+	clock := &instantClock{}
+	attempts := 0
+
+	result, err := RetryFunc(context.Background(), func(ctx context.Context, a Attempt) (string, error) {
+		attempts++
+		if a.Number == 1 {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "success", nil
+	}, StopOnErrors(context.DeadlineExceeded), AttemptTimeout(5*time.Millisecond),
+		WithClock(clock), InitialDelay(1*time.Millisecond), MaxRetries(3))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "success" {
+		t.Errorf("Expected result 'success', got %v", result)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryWhile(t *testing.T) {
+	statuses := []string{"PENDING", "PENDING", "DONE"}
+	attempts := 0
+
+	result, err := Retry(func() (string, error) {
+		status := statuses[attempts]
+		attempts++
+		return status, nil
+	}, RetryWhile(func(status string, _ error) bool {
+		return status == "PENDING"
+	}), InitialDelay(1*time.Millisecond), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "DONE" {
+		t.Errorf("Expected result 'DONE', got %v", result)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryWhileConditionNotMet(t *testing.T) {
+	attempts := 0
+
+	result, err := Retry(func() (int, error) {
+		attempts++
+		return attempts, nil
+	}, RetryWhile(func(n int, _ error) bool {
+		return n < 100
+	}), InitialDelay(1*time.Millisecond), MaxRetries(2))
+
+	if !errors.Is(err, ErrConditionNotMet) {
+		t.Errorf("Expected ErrConditionNotMet, got %v", err)
+	}
+	if result != 3 { // last value is returned
+		t.Errorf("Expected result 3, got %v", result)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryWhilePendingThenError(t *testing.T) {
+	clock := &instantClock{}
+	boom := errors.New("boom")
+
+	job, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (*string, error) {
+		if a.Number == 1 {
+			pending := "PENDING"
+			return &pending, nil
+		}
+		return nil, boom
+	}, RetryWhile(func(status *string, err error) bool {
+		return err == nil && *status == "PENDING"
+	}), WithClock(clock), MaxRetries(1))
+
+	if errors.Is(err, ErrConditionNotMet) {
+		t.Errorf("Expected no ErrConditionNotMet after a failed last attempt, got %v", err)
+	}
+	if !errors.Is(err, boom) {
+		t.Errorf("Expected the last error, got %v", err)
+	}
+	if job != nil {
+		t.Errorf("Expected nil result, got %v", *job)
+	}
+}
+
+func TestRetryWhilePrevErr(t *testing.T) {
+	var prevErrs []error
+
+	_, _ = RetryFunc(context.Background(), func(_ context.Context, a Attempt) (bool, error) {
+		prevErrs = append(prevErrs, a.PrevErr)
+		return a.Number == 2, nil
+	}, RetryWhile(func(ready bool, _ error) bool {
+		return !ready
+	}), InitialDelay(1*time.Millisecond), MaxRetries(5))
+
+	if len(prevErrs) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(prevErrs))
+	}
+	if prevErrs[1] != ErrConditionNotMet {
+		t.Errorf("Expected previous error ErrConditionNotMet, got %v", prevErrs[1])
+	}
+}
+
+func TestRetryWhileStillRetriesErrors(t *testing.T) {
+	attempts := 0
+
+	result, err := Retry(func() (*string, error) {
+		attempts++
+		if attempts < 2 {
+			return nil, errors.New("temporary failure")
+		}
+		done := "DONE"
+		return &done, nil
+	}, RetryWhile(func(status *string, err error) bool {
+		return err == nil && status == nil
+	}), InitialDelay(1*time.Millisecond), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result == nil || *result != "DONE" {
+		t.Errorf("Expected result 'DONE', got %v", result)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryWhileOnError(t *testing.T) {
+	clock := &instantClock{}
+	errNotReady := errors.New("not ready")
+	attempts := 0
+
+	// The predicate retries an error that the classifier would otherwise stop on
+	result, err := Retry(func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errNotReady
+		}
+		return attempts, nil
+	}, RetryWhile(func(_ int, err error) bool {
+		return errors.Is(err, errNotReady)
+	}), StopOnErrors(errNotReady), WithClock(clock), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != 3 {
+		t.Errorf("Expected result 3, got %v", result)
+	}
+}
+
+func TestRetryWhileCancelErrorStops(t *testing.T) {
+	clock := &instantClock{}
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, Cancel(errors.New("permanent"))
+	}, RetryWhile(func(int, error) bool { return true }), WithClock(clock), MaxRetries(5))
+
+	if !IsCancel(err) {
+		t.Errorf("Expected cancel error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryWhileTypeMismatch(t *testing.T) {
+	clock := &instantClock{}
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 1, nil
+	}, RetryWhile(func(string, error) bool { return true }), WithClock(clock), MaxRetries(5))
+
+	if !IsCancel(err) {
+		t.Errorf("Expected cancel error, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "RetryWhile[string] used with a function returning int") {
+		t.Errorf("Expected error describing the mismatch, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryFuncWhile(t *testing.T) {
+	clock := &instantClock{}
+	statuses := []string{"PENDING", "PENDING", "DONE"}
+
+	result, err := RetryFuncWhile(context.Background(), func(_ context.Context, a Attempt) (string, error) {
+		return statuses[a.Number-1], nil
+	}, func(status string, _ error) bool {
+		return status == "PENDING"
+	}, WithClock(clock), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if result != "DONE" {
+		t.Errorf("Expected result 'DONE', got %v", result)
+	}
+	if len(clock.sleeps) != 2 {
+		t.Errorf("Expected 2 delays, got %d", len(clock.sleeps))
+	}
+}
+
+func TestRetryErrorRecordsAttempts(t *testing.T) {
+	errs := []error{errors.New("first"), errors.New("second"), errors.New("third")}
+	start := time.Now()
+
+	_, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (int, error) {
+		return 0, errs[a.Number-1]
+	}, InitialDelay(1*time.Millisecond), Multiplier(2.0), JitterFactor(0), MaxRetries(2))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %v", err)
+	}
+	if retryErr.ContextErr != nil {
+		t.Errorf("Expected no context error, got %v", retryErr.ContextErr)
+	}
+	if len(retryErr.Attempts) != 3 {
+		t.Fatalf("Expected 3 recorded attempts, got %d", len(retryErr.Attempts))
+	}
+
+	expectedDelays := []time.Duration{0, 1 * time.Millisecond, 2 * time.Millisecond}
+	prevStart := start
+	for i, a := range retryErr.Attempts {
+		if a.Number != i+1 {
+			t.Errorf("Attempt %d: expected number %d, got %d", i, i+1, a.Number)
+		}
+		if a.Err != errs[i] {
+			t.Errorf("Attempt %d: expected error %v, got %v", i, errs[i], a.Err)
+		}
+		if a.Delay != expectedDelays[i] {
+			t.Errorf("Attempt %d: expected delay %v, got %v", i, expectedDelays[i], a.Delay)
+		}
+		if a.Start.Before(prevStart) {
+			t.Errorf("Attempt %d: start time %v is before previous %v", i, a.Start, prevStart)
+		}
+		prevStart = a.Start
+	}
+
+	for _, target := range errs {
+		if !errors.Is(err, target) {
+			t.Errorf("Expected errors.Is to match %v", target)
+		}
+	}
+	if retryErr.Last() != errs[2] {
+		t.Errorf("Expected last error %v, got %v", errs[2], retryErr.Last())
+	}
+}
+
+func TestRetryErrorContextCancellation(t *testing.T) {
+	failure := errors.New("always fails")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := RetryWithContext(ctx, func() (int, error) {
+		return 0, failure
+	}, Constant(), InitialDelay(time.Second))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %v", err)
+	}
+	if retryErr.ContextErr != context.DeadlineExceeded {
+		t.Errorf("Expected context error DeadlineExceeded, got %v", retryErr.ContextErr)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected errors.Is to match DeadlineExceeded")
+	}
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected errors.Is to match the last failure")
+	}
+}
+
+func TestRetryErrorMessage(t *testing.T) {
+	err := &RetryError{Attempts: []AttemptError{
+		{Number: 1, Err: errors.New("connection refused")},
+		{Number: 2, Delay: 100 * time.Millisecond, Err: errors.New("connection reset")},
+	}}
+
+	expected := "backoff: 2 attempts failed, last error: connection reset\n" +
+		"  attempt 1: connection refused\n" +
+		"  attempt 2 (after 100ms): connection reset"
+	if err.Error() != expected {
+		t.Errorf("Expected message:\n%s\ngot:\n%s", expected, err.Error())
+	}
+
+	err.ContextErr = context.DeadlineExceeded
+	expected = "backoff: context deadline exceeded after 2 attempts, last error: connection reset\n" +
+		"  attempt 1: connection refused\n" +
+		"  attempt 2 (after 100ms): connection reset"
+	if err.Error() != expected {
+		t.Errorf("Expected message:\n%s\ngot:\n%s", expected, err.Error())
+	}
+
+	err.ContextErr, err.StopErr = nil, ErrCircuitOpen
+	expected = "backoff: circuit breaker is open after 2 attempts, last error: connection reset\n" +
+		"  attempt 1: connection refused\n" +
+		"  attempt 2 (after 100ms): connection reset"
+	if err.Error() != expected {
+		t.Errorf("Expected message:\n%s\ngot:\n%s", expected, err.Error())
+	}
+}
+
+func TestRetryErrorMessageTruncated(t *testing.T) {
+	err := &RetryError{}
+	for i := range 25 {
+		err.Attempts = append(err.Attempts, AttemptError{Number: i + 1, Err: fmt.Errorf("failure %d", i+1)})
+	}
+
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 12 { // summary + 5 first + elision + 5 last
+		t.Fatalf("Expected 12 lines, got %d:\n%s", len(lines), err.Error())
+	}
+	if lines[6] != "  ... 15 more attempts" {
+		t.Errorf("Expected elision line, got %q", lines[6])
+	}
+	if lines[11] != "  attempt 25: failure 25" {
+		t.Errorf("Expected last attempt line, got %q", lines[11])
+	}
+}
+
+// hintError is an error from another library that carries a Retry-After hint.
+type hintError struct{ delay time.Duration }
+
+func (e hintError) Error() string             { return "throttled" }
+func (e hintError) RetryAfter() time.Duration { return e.delay }
+
+func TestRetryAfterOverridesSchedule(t *testing.T) {
+	clock := &instantClock{}
+	errs := []error{
+		RetryAfter(errors.New("rate limited"), 5*time.Second),
+		errors.New("plain failure"),
+		fmt.Errorf("fetch: %w", hintError{delay: 3 * time.Second}),
+	}
+
+	var delays []time.Duration
+	_, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (int, error) {
+		delays = append(delays, a.Delay)
+		if a.Number <= len(errs) {
+			return 0, errs[a.Number-1]
+		}
+		return 42, nil
+	}, WithClock(clock), Constant(), InitialDelay(time.Second), MaxDelay(time.Minute), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	expected := []time.Duration{0, 5 * time.Second, time.Second, 3 * time.Second}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d attempts, got %d: %v", len(expected), len(delays), delays)
+	}
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Attempt %d: expected delay %v, got %v", i+1, expectedDelay, delays[i])
+		}
+	}
+	for i, expectedSleep := range expected[1:] {
+		if clock.sleeps[i] != expectedSleep {
+			t.Errorf("Sleep %d: expected %v, got %v", i, expectedSleep, clock.sleeps[i])
+		}
+	}
+}
+
+func TestRetryAfterClampedByMaxDelay(t *testing.T) {
+	clock := &instantClock{}
+
+	_, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (int, error) {
+		return 0, RetryAfter(errors.New("unavailable"), time.Hour)
+	}, WithClock(clock), MaxDelay(time.Minute), MaxRetries(2))
+
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected RetryError, got %v", err)
+	}
+	for _, a := range retryErr.Attempts[1:] {
+		if a.Delay != time.Minute {
+			t.Errorf("Attempt %d: expected delay %v, got %v", a.Number, time.Minute, a.Delay)
+		}
+	}
+}
+
+func TestRetryAfterWithinDeadline(t *testing.T) {
+	clock := &instantClock{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	var delays []time.Duration
+	_, err := RetryFunc(ctx, func(_ context.Context, a Attempt) (int, error) {
+		delays = append(delays, a.Delay)
+		if a.Number == 1 {
+			return 0, RetryAfter(errors.New("unavailable"), time.Minute)
+		}
+		return 1, nil
+	}, WithClock(clock), MaxDelay(time.Hour))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(delays) != 2 || delays[1] != time.Minute {
+		t.Errorf("Expected second attempt after %v, got %v", time.Minute, delays)
+	}
+}
+
+func TestRetryAfterBeyondDeadline(t *testing.T) {
+	clock := &instantClock{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	_, err := RetryFunc(ctx, func(_ context.Context, a Attempt) (int, error) {
+		calls++
+		return 0, RetryAfter(errors.New("unavailable"), time.Second)
+	}, WithClock(clock), MaxDelay(time.Hour))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.ContextErr != context.DeadlineExceeded {
+		t.Fatalf("Expected RetryError with DeadlineExceeded, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single call, got %d", calls)
+	}
+	if len(clock.sleeps) != 0 {
+		t.Errorf("Expected no sleep past the deadline, got %v", clock.sleeps)
+	}
+}
+
+func TestRetryAfterClampedByMaxElapsedTime(t *testing.T) {
+	clock := &instantClock{}
+
+	var delays []time.Duration
+	_, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (int, error) {
+		delays = append(delays, a.Delay)
+		return 0, RetryAfter(errors.New("unavailable"), 30*time.Second)
+	}, WithClock(clock), MaxElapsedTime(time.Second), MaxDelay(time.Hour))
+
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	var total time.Duration
+	for _, sleep := range clock.sleeps {
+		total += sleep
+	}
+	if total > time.Second {
+		t.Errorf("Expected at most %v of sleep, got %v: %v", time.Second, total, clock.sleeps)
+	}
+	if len(delays) < 2 || delays[1] != time.Second {
+		t.Errorf("Expected second attempt after %v, got %v", time.Second, delays)
+	}
+}
+
+func TestRetryAfterNegativeHint(t *testing.T) {
+	clock := &instantClock{}
+
+	var delays []time.Duration
+	_, err := RetryFunc(context.Background(), func(_ context.Context, a Attempt) (int, error) {
+		delays = append(delays, a.Delay)
+		if a.Number == 1 {
+			return 0, RetryAfter(errors.New("unavailable"), -time.Second)
+		}
+		return 1, nil
+	}, WithClock(clock))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(delays) != 2 || delays[1] != 0 {
+		t.Errorf("Expected second attempt without delay, got %v", delays)
+	}
+}
+
+func TestRetryAfterError(t *testing.T) {
+	base := errors.New("rate limited")
+	err := RetryAfter(base, time.Second)
+
+	if err.Error() != "rate limited" {
+		t.Errorf("Expected message 'rate limited', got %q", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Errorf("Expected RetryAfter error to unwrap to the original error")
+	}
+	var hint RetryAfterError
+	if !errors.As(err, &hint) || hint.RetryAfter() != time.Second {
+		t.Errorf("Expected RetryAfterError with delay %v, got %v", time.Second, err)
+	}
+}
@@ -0,0 +1,182 @@
+package backoff
+
+import (
+	"math"
+	"time"
+)
+
+// Strategy computes the base delay for each retry attempt.
+//
+// Next is called with the zero-based attempt number and the previous base delay
+// (zero on the first attempt). It returns the delay to wait before the attempt and
+// whether the schedule should continue. Returning false ends the iteration early,
+// regardless of MaxRetries.
+//
+// The delay returned by a Strategy is the base delay: Iter caps it at MaxDelay,
+// feeds the capped value back as prev on the next call, and applies jitter to the
+// value it yields. Strategies should therefore be deterministic unless randomness is
+// part of the algorithm itself.
+type Strategy interface {
+	Next(attempt int, prev time.Duration) (time.Duration, bool)
+}
+
+// StrategyFunc is an adapter that allows the use of ordinary functions as a Strategy.
+type StrategyFunc func(attempt int, prev time.Duration) (time.Duration, bool)
+
+// Next calls f(attempt, prev).
+func (f StrategyFunc) Next(attempt int, prev time.Duration) (time.Duration, bool) {
+	return f(attempt, prev)
+}
+
+// WithStrategy returns an Option that replaces the delay schedule with s.
+// MaxDelay, jitter and MaxRetries are still applied on top of the delays s returns.
+//
+// Example:
+//
+//	linear := backoff.StrategyFunc(func(attempt int, _ time.Duration) (time.Duration, bool) {
+//	    return time.Duration(attempt+1) * time.Second, true
+//	})
+//	for delay := range backoff.Iter(backoff.WithStrategy(linear), backoff.MaxRetries(3)) {
+//	    // Delays will be ~1s, ~2s, ~3s
+//	}
+func WithStrategy(s Strategy) Option {
+	return func(c *config) {
+		c.strategy = func(*config) Strategy { return s }
+	}
+}
+
+// exponential multiplies the previous delay by a constant factor.
+type exponential struct {
+	initial    time.Duration
+	multiplier float64
+}
+
+func newExponential(c *config) Strategy {
+	return exponential{initial: c.initialDelay, multiplier: c.multiplier}
+}
+
+func (e exponential) Next(attempt int, prev time.Duration) (time.Duration, bool) {
+	if attempt == 0 {
+		return e.initial, true
+	}
+	next := float64(prev) * e.multiplier
+	if next >= math.MaxInt64 {
+		return math.MaxInt64, true
+	}
+	return time.Duration(next), true
+}
+
+// constant always returns the same delay.
+type constant struct {
+	delay time.Duration
+}
+
+// newConstant returns a constant strategy, or an exponential one if a later Multiplier
+// option raised the multiplier, since Constant only presets the fields of Exponential.
+func newConstant(c *config) Strategy {
+	if c.multiplier > 1 {
+		return newExponential(c)
+	}
+	return constant{delay: c.initialDelay}
+}
+
+func (s constant) Next(int, time.Duration) (time.Duration, bool) {
+	return s.delay, true
+}
+
+// decorrelated implements the "decorrelated jitter" algorithm: each delay is drawn
+// uniformly from [base, prev*3], so clients that start together drift apart quickly.
+type decorrelated struct {
+	base    time.Duration
+	float64 func() float64
+}
+
+func newDecorrelated(c *config) Strategy {
+	return decorrelated{base: c.initialDelay, float64: c.float64}
+}
+
+func (s decorrelated) Next(_ int, prev time.Duration) (time.Duration, bool) {
+	prev = max(prev, s.base)
+	upper := float64(prev) * 3
+	if upper >= math.MaxInt64 {
+		upper = math.MaxInt64
+	}
+	return time.Duration(float64(s.base) + s.float64()*(upper-float64(s.base))), true
+}
+
+// fibonacci multiplies the initial delay by the Fibonacci sequence 1, 1, 2, 3, 5, 8, ...
+type fibonacci struct {
+	initial time.Duration
+}
+
+func newFibonacci(c *config) Strategy {
+	return fibonacci{initial: c.initialDelay}
+}
+
+func (s fibonacci) Next(attempt int, _ time.Duration) (time.Duration, bool) {
+	a, b := time.Duration(1), time.Duration(1)
+	for range attempt {
+		if b > math.MaxInt64/s.initial-a {
+			return math.MaxInt64, true
+		}
+		a, b = b, a+b
+	}
+	return s.initial * a, true
+}
+
+// linear adds a constant step to the delay on each attempt.
+type linear struct {
+	initial time.Duration
+	step    time.Duration
+}
+
+func (s linear) Next(attempt int, _ time.Duration) (time.Duration, bool) {
+	if s.step > 0 && time.Duration(attempt) > (math.MaxInt64-s.initial)/s.step {
+		return math.MaxInt64, true
+	}
+	return s.initial + time.Duration(attempt)*s.step, true
+}
+
+// polynomial multiplies the initial delay by (attempt+1)^exponent.
+type polynomial struct {
+	initial  time.Duration
+	exponent float64
+}
+
+func (s polynomial) Next(attempt int, _ time.Duration) (time.Duration, bool) {
+	next := float64(s.initial) * math.Pow(float64(attempt+1), s.exponent)
+	if next >= math.MaxInt64 {
+		return math.MaxInt64, true
+	}
+	return time.Duration(next), true
+}
+
+// schedule yields a fixed list of delays, then stops, repeats the last one or starts over.
+type schedule struct {
+	delays []time.Duration
+	mode   scheduleMode
+}
+
+type scheduleMode int
+
+const (
+	scheduleOnce scheduleMode = iota
+	scheduleRepeatLast
+	scheduleCycle
+)
+
+func (s schedule) Next(attempt int, _ time.Duration) (time.Duration, bool) {
+	n := len(s.delays)
+	switch {
+	case n == 0:
+		return 0, false
+	case attempt < n:
+		return s.delays[attempt], true
+	case s.mode == scheduleRepeatLast:
+		return s.delays[n-1], true
+	case s.mode == scheduleCycle:
+		return s.delays[attempt%n], true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,329 @@
+package backoff
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+var errTemporary = errors.New("temporary failure")
+
+func TestFibonacciBackoff(t *testing.T) {
+	var delays []time.Duration
+	for delay := range Iter(
+		Fibonacci(),
+		InitialDelay(100*time.Millisecond),
+		MaxDelay(1*time.Second),
+		JitterFactor(0),
+		MaxRetries(8),
+	) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+		500 * time.Millisecond,
+		800 * time.Millisecond,
+		1 * time.Second, // capped at max delay (1.3s)
+		1 * time.Second,
+	}
+
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d", len(expected), len(delays))
+	}
+
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestFibonacciBackoffWithJitter(t *testing.T) {
+	baseDelays := []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+
+	i := 0
+	for delay := range Iter(Fibonacci(), MaxRetries(4)) {
+		base := baseDelays[i]
+		minDelay := time.Duration(float64(base) * 0.9)
+		maxDelay := time.Duration(float64(base) * 1.1)
+
+		if delay < minDelay || delay > maxDelay {
+			t.Errorf("Delay %d: %v is outside expected jitter range [%v, %v]", i, delay, minDelay, maxDelay)
+		}
+		i++
+	}
+
+	if i != 4 {
+		t.Errorf("Expected 4 delays, got %d", i)
+	}
+}
+
+func TestFibonacciOverflow(t *testing.T) {
+	s := fibonacci{initial: time.Second}
+
+	delay, ok := s.Next(200, 0)
+	if !ok {
+		t.Fatalf("Expected fibonacci strategy to continue")
+	}
+	if delay != math.MaxInt64 {
+		t.Errorf("Expected delay to saturate at %v, got %v", time.Duration(math.MaxInt64), delay)
+	}
+}
+
+func TestLinearBackoff(t *testing.T) {
+	var delays []time.Duration
+	for delay := range Iter(
+		Linear(100*time.Millisecond),
+		MaxDelay(350*time.Millisecond),
+		JitterFactor(0),
+		MaxRetries(5),
+	) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+		350 * time.Millisecond, // capped at max delay
+		350 * time.Millisecond,
+	}
+
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d", len(expected), len(delays))
+	}
+
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestLinearBackoffWithInitialDelay(t *testing.T) {
+	var delays []time.Duration
+	for delay := range Iter(
+		Linear(time.Second),
+		InitialDelay(5*time.Second),
+		JitterFactor(0),
+		MaxRetries(3),
+	) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{5 * time.Second, 6 * time.Second, 7 * time.Second}
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestPolynomialBackoff(t *testing.T) {
+	var delays []time.Duration
+	for delay := range Iter(
+		Polynomial(2),
+		InitialDelay(10*time.Millisecond),
+		MaxDelay(200*time.Millisecond),
+		JitterFactor(0),
+		MaxRetries(6),
+	) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{
+		10 * time.Millisecond,  // 1²
+		40 * time.Millisecond,  // 2²
+		90 * time.Millisecond,  // 3²
+		160 * time.Millisecond, // 4²
+		200 * time.Millisecond, // capped at max delay (250ms)
+		200 * time.Millisecond,
+	}
+
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d", len(expected), len(delays))
+	}
+
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestLinearAndPolynomialDefaults(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"linear":     Linear(0),
+		"polynomial": Polynomial(-1),
+	} {
+		var delays []time.Duration
+		for delay := range Iter(opt, JitterFactor(0), MaxRetries(2)) {
+			delays = append(delays, delay)
+		}
+		if len(delays) != 2 || delays[0] != 100*time.Millisecond {
+			t.Errorf("%s: expected first delay 100ms, got %v", name, delays)
+		}
+	}
+}
+
+func TestLinearAndPolynomialOverflow(t *testing.T) {
+	if delay, _ := (linear{initial: time.Second, step: time.Hour}).Next(math.MaxInt, 0); delay != math.MaxInt64 {
+		t.Errorf("Expected linear delay to saturate, got %v", delay)
+	}
+	if delay, _ := (polynomial{initial: time.Second, exponent: 10}).Next(1e6, 0); delay != math.MaxInt64 {
+		t.Errorf("Expected polynomial delay to saturate, got %v", delay)
+	}
+}
+
+func TestRetryLinear(t *testing.T) {
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errTemporary
+		}
+		return 1, nil
+	}, Linear(time.Millisecond), MaxRetries(5))
+
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	var delays []time.Duration
+	for delay := range Iter(Schedule(time.Second, 5*time.Second, 30*time.Second, 2*time.Minute)) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 2 * time.Minute}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d", len(expected), len(delays))
+	}
+
+	for i, expectedDelay := range expected {
+		if delays[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, delays[i])
+		}
+	}
+}
+
+func TestScheduleModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   Option
+		expected []time.Duration
+	}{
+		{
+			"repeat last",
+			ScheduleRepeatLast(time.Second, 5*time.Second, 5*time.Minute),
+			[]time.Duration{time.Second, 5 * time.Second, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+		},
+		{
+			"cycle",
+			ScheduleCycle(time.Second, 10*time.Second),
+			[]time.Duration{time.Second, 10 * time.Second, time.Second, 10 * time.Second, time.Second},
+		},
+		{
+			"max retries shortens schedule",
+			Schedule(time.Second, 2*time.Second, 3*time.Second, 4*time.Second, 5*time.Second, 6*time.Second),
+			[]time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		var delays []time.Duration
+		for delay := range Iter(tt.option, MaxRetries(5)) {
+			delays = append(delays, delay)
+		}
+
+		if len(delays) != len(tt.expected) {
+			t.Fatalf("%s: expected %d delays, got %d", tt.name, len(tt.expected), len(delays))
+		}
+		for i, expectedDelay := range tt.expected {
+			if delays[i] != expectedDelay {
+				t.Errorf("%s: delay %d: expected %v, got %v", tt.name, i, expectedDelay, delays[i])
+			}
+		}
+	}
+}
+
+func TestScheduleEmpty(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"once":        Schedule(),
+		"repeat last": ScheduleRepeatLast(),
+		"cycle":       ScheduleCycle(),
+	} {
+		for range Iter(opt) {
+			t.Errorf("%s: expected no delays from an empty schedule", name)
+			break
+		}
+	}
+}
+
+func TestScheduleWithJitterAndMaxDelay(t *testing.T) {
+	i := 0
+	for delay := range Iter(Schedule(100*time.Millisecond, time.Second), JitterFactor(0.1), MaxDelay(500*time.Millisecond)) {
+		switch i {
+		case 0:
+			if delay < 90*time.Millisecond || delay > 110*time.Millisecond {
+				t.Errorf("Delay 0: %v is outside expected jitter range [90ms, 110ms]", delay)
+			}
+		case 1:
+			// Jitter is applied to the capped delay and can only lower it below the cap
+			if delay < 450*time.Millisecond || delay > 500*time.Millisecond {
+				t.Errorf("Delay 1: %v is outside expected capped range [450ms, 500ms]", delay)
+			}
+		}
+		i++
+	}
+
+	if i != 2 {
+		t.Errorf("Expected 2 delays, got %d", i)
+	}
+}
+
+func TestScheduleCopiesDelays(t *testing.T) {
+	delays := []time.Duration{time.Second, 2 * time.Second}
+	opt := Schedule(delays...)
+	delays[0] = time.Hour
+
+	for delay := range Iter(opt, MaxRetries(1)) {
+		if delay != time.Second {
+			t.Errorf("Expected schedule to be unaffected by later changes, got %v", delay)
+		}
+	}
+}
+
+func TestRetryWithSchedule(t *testing.T) {
+	clock := &instantClock{}
+	attempts := 0
+
+	_, err := Retry(func() (int, error) {
+		attempts++
+		return 0, errTemporary
+	}, WithClock(clock), ScheduleRepeatLast(time.Second, 5*time.Second, time.Minute), MaxRetries(4))
+
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+	if attempts != 5 {
+		t.Errorf("Expected 5 attempts, got %d", attempts)
+	}
+
+	expected := []time.Duration{time.Second, 5 * time.Second, time.Minute, time.Minute}
+	for i, expectedDelay := range expected {
+		if clock.sleeps[i] != expectedDelay {
+			t.Errorf("Sleep %d: expected %v, got %v", i, expectedDelay, clock.sleeps[i])
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package backoff
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker delivers ticks on a channel following a backoff schedule, for select loops that
+// multiplex several channels and cannot range over Iter. It mirrors time.Ticker, except
+// that the interval between ticks follows the configured delays and C is closed once the
+// schedule is exhausted (for example after MaxRetries ticks).
+//
+// Each tick is sent when its delay has elapsed and the next delay only starts once the tick
+// has been received, so ticks are never dropped for slow receivers.
+//
+// Example:
+//
+//	ticker := backoff.NewTicker(backoff.MaxRetries(5))
+//	defer ticker.Stop()
+//
+//	for {
+//	    select {
+//	    case _, ok := <-ticker.C:
+//	        if !ok {
+//	            return errors.New("gave up reconnecting")
+//	        }
+//	        if err := connect(); err == nil {
+//	            return nil
+//	        }
+//	    case msg := <-messages:
+//	        handle(msg)
+//	    }
+//	}
+type Ticker struct {
+	// C is the channel on which ticks are delivered. It is closed when the schedule is exhausted.
+	C <-chan time.Time
+
+	c       chan time.Time
+	backoff *Backoff
+
+	control sync.Mutex // serializes Stop and Reset
+
+	mu     sync.Mutex
+	stop   chan struct{} // closed to stop the running goroutine; nil when not running
+	done   chan struct{} // closed when the running goroutine exits
+	closed bool          // c has been closed
+}
+
+// NewTicker returns a Ticker whose ticks follow the delays of Iter with the same options.
+// The first tick is delivered after the first delay.
+func NewTicker(options ...Option) *Ticker {
+	c := make(chan time.Time)
+	t := &Ticker{
+		C:       c,
+		c:       c,
+		backoff: New(options...),
+	}
+	t.start()
+	return t
+}
+
+// Stop turns off the ticker. After Stop returns, no more ticks will be sent.
+// Like time.Ticker, Stop does not close C.
+func (t *Ticker) Stop() {
+	t.control.Lock()
+	defer t.control.Unlock()
+	t.halt()
+}
+
+// Reset stops the ticker and restarts its schedule from the first delay. Reset can also
+// restart a stopped ticker, but has no effect once C has been closed. Stop and Reset are
+// safe to call concurrently.
+func (t *Ticker) Reset() {
+	t.control.Lock()
+	defer t.control.Unlock()
+	t.halt()
+	t.backoff.Reset()
+	t.start()
+}
+
+// halt stops the running goroutine, if any, and waits for it to exit.
+func (t *Ticker) halt() {
+	t.mu.Lock()
+	stop, done := t.stop, t.done
+	t.stop = nil
+	t.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// start launches the goroutine delivering ticks unless C is already closed.
+func (t *Ticker) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return
+	}
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+	go t.run(t.stop, t.done)
+}
+
+func (t *Ticker) run(stop, done chan struct{}) {
+	defer close(done)
+
+	clock := t.backoff.cfg.clock
+	for {
+		delay, ok := t.backoff.Next()
+		if !ok {
+			t.mu.Lock()
+			t.closed = true
+			close(t.c)
+			t.mu.Unlock()
+			return
+		}
+
+		timer := clock.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case now := <-timer.C():
+			select {
+			case t.c <- now:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
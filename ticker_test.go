@@ -0,0 +1,129 @@
+package backoff
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTickerClosesWhenExhausted(t *testing.T) {
+	clock := &instantClock{}
+	ticker := NewTicker(WithClock(clock), InitialDelay(time.Second), JitterFactor(0), MaxRetries(3))
+	defer ticker.Stop()
+
+	ticks := 0
+	for range ticker.C {
+		ticks++
+	}
+
+	if ticks != 3 {
+		t.Errorf("Expected 3 ticks, got %d", ticks)
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	if len(clock.sleeps) != len(expected) {
+		t.Fatalf("Expected %d delays, got %d", len(expected), len(clock.sleeps))
+	}
+	for i, expectedDelay := range expected {
+		if clock.sleeps[i] != expectedDelay {
+			t.Errorf("Delay %d: expected %v, got %v", i, expectedDelay, clock.sleeps[i])
+		}
+	}
+}
+
+func TestTickerRealTime(t *testing.T) {
+	ticker := NewTicker(InitialDelay(5*time.Millisecond), JitterFactor(0), MaxRetries(2))
+	defer ticker.Stop()
+
+	start := time.Now()
+	<-ticker.C
+	<-ticker.C
+	if _, ok := <-ticker.C; ok {
+		t.Errorf("Expected C to be closed after 2 ticks")
+	}
+
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond { // 5ms + 10ms
+		t.Errorf("Expected ticks to follow the backoff delays, took %v", elapsed)
+	}
+}
+
+func TestTickerStop(t *testing.T) {
+	ticker := NewTicker(Constant(), InitialDelay(time.Millisecond))
+
+	<-ticker.C
+	ticker.Stop()
+	ticker.Stop() // stopping twice is safe
+
+	select {
+	case <-ticker.C:
+		t.Errorf("Expected no ticks after Stop")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestTickerReset(t *testing.T) {
+	clock := &instantClock{}
+	ticker := NewTicker(WithClock(clock), InitialDelay(time.Second), JitterFactor(0), MaxRetries(3))
+	defer ticker.Stop()
+
+	<-ticker.C
+	<-ticker.C
+	ticker.Reset()
+
+	// The schedule starts over, so three more ticks are delivered before C closes
+	ticks := 0
+	for range ticker.C {
+		ticks++
+	}
+	if ticks != 3 {
+		t.Errorf("Expected 3 ticks after Reset, got %d", ticks)
+	}
+}
+
+func TestTickerResetAfterStop(t *testing.T) {
+	clock := &instantClock{}
+	ticker := NewTicker(WithClock(clock), InitialDelay(time.Second), MaxRetries(2))
+	defer ticker.Stop()
+
+	ticker.Stop()
+	ticker.Reset()
+
+	ticks := 0
+	for range ticker.C {
+		ticks++
+	}
+	if ticks != 2 {
+		t.Errorf("Expected 2 ticks after restarting a stopped ticker, got %d", ticks)
+	}
+
+	ticker.Reset() // no effect once C is closed
+	if _, ok := <-ticker.C; ok {
+		t.Errorf("Expected C to stay closed after Reset")
+	}
+}
+
+func TestTickerConcurrentReset(t *testing.T) {
+	clock := &instantClock{}
+	ticker := NewTicker(WithClock(clock), Constant(), InitialDelay(time.Second))
+	defer ticker.Stop()
+
+	for range 1000 {
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticker.Reset()
+			}()
+		}
+		wg.Wait()
+	}
+	ticker.Stop()
+
+	// A goroutine started by a racing Reset would keep delivering ticks
+	select {
+	case <-ticker.C:
+		t.Errorf("Expected no ticks after Stop following concurrent Resets")
+	case <-time.After(20 * time.Millisecond):
+	}
+}